@@ -0,0 +1,567 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// IssueKind identifies the kind of an Issue reported by Router.Validate.
+type IssueKind int
+
+const (
+	// IssueShadowed means that a request which the Route's pattern matches
+	// is resolved to another pattern with a higher priority, e.g. a static
+	// one, which has no Route for the request's method, i.e. the request
+	// gets a 405 instead of reaching the Route.
+	IssueShadowed IssueKind = iota + 1
+	// IssueUnreachable means that a request which the Route's pattern
+	// matches is not found, or redirected, because the lookup followed the
+	// edges of other patterns with a higher priority and does not backtrack,
+	// or because the param rules of such a pattern rejected the request.
+	IssueUnreachable
+	// IssueHostOverlap means that a request which the Route's pattern
+	// matches is resolved differently because of its host, i.e. by a
+	// pattern that begins with a host or by a sub-router.
+	IssueHostOverlap
+	// IssueRedirectLoop means that the trailing slash redirects of a
+	// request which the Route's pattern matches end up in a loop.
+	IssueRedirectLoop
+	// IssueParamNames means that the Route's pattern differs from another
+	// one only in the names of its params, e.g. the patterns of the GET and
+	// DELETE Routes of the same resource.
+	IssueParamNames
+)
+
+// String returns the name of the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueShadowed:
+		return "shadowed"
+	case IssueUnreachable:
+		return "unreachable"
+	case IssueHostOverlap:
+		return "host overlap"
+	case IssueRedirectLoop:
+		return "redirect loop"
+	case IssueParamNames:
+		return "param names"
+	}
+	return fmt.Sprintf("IssueKind(%d)", int(k))
+}
+
+// Issue describes a problem with a Route found by Router.Validate.
+type Issue struct {
+	Kind IssueKind
+	// The host pattern of the sub-router with which the Route was
+	// registered, empty if the Route was registered with a root Router.
+	Host string
+	// The pattern of the Route.
+	Pattern string
+	// The method of the request that exhibits the issue, or the
+	// comma separated methods of the Route if the issue has no request.
+	Method string
+	// The pattern of the other Route involved in the issue, if any.
+	Other string
+	// The path of the request that exhibits the issue, if any.
+	Path string
+	// The description of the issue.
+	Message string
+}
+
+// String returns the issue in the form "kind: METHOD pattern: message".
+func (i Issue) String() string {
+	var sb strings.Builder
+	sb.WriteString(i.Kind.String())
+	sb.WriteString(": ")
+	if i.Method != "" {
+		sb.WriteString(i.Method)
+		sb.WriteByte(' ')
+	}
+	if i.Host != "" {
+		sb.WriteString("[" + i.Host + "] ")
+	}
+	sb.WriteString(fmt.Sprintf("%q: ", i.Pattern))
+	sb.WriteString(i.Message)
+	return sb.String()
+}
+
+// ValidationError is returned by Router.Validate, it holds the issues
+// found with the Router's Routes.
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error returns the issues, one per line.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, is := range e.Issues {
+		msgs[i] = is.String()
+	}
+	return fmt.Sprintf("route: %d issues with the routes:\n", len(e.Issues)) + strings.Join(msgs, "\n")
+}
+
+// Validate analyzes the Router's Routes, and those of its sub-routers, and returns
+// a *ValidationError that lists the issues it found, or nil if there are none. It
+// reports the Routes that are shadowed by, or unreachable because of, patterns with
+// a higher priority, e.g. a param pattern whose requests are taken by a static one
+// that has no Route for their method, or a catch-all pattern that loses requests to
+// a param since the lookup does not backtrack. It also reports the non-host Routes
+// that are taken over by patterns that begin with a host or by sub-routers, the
+// trailing slash redirects that loop, and the patterns that differ from each other
+// only in the names of their params.
+//
+// Validate resolves example requests for each Route's pattern, with the params set
+// to placeholder values and to the static segments of the other patterns, the same
+// way Explain does, which makes it slow for large route sets. It is meant to be run
+// in tests, e.g. as part of a service's CI, rather than when serving requests. The
+// kinds of issues that a service expects, e.g. the IssueShadowed issues of params
+// whose values never equal the static segments, can be filtered out of the Issues.
+func (r *Router) Validate() error {
+	v := &validator{r: r, seen: make(map[string]bool)}
+	v.collect()
+	v.checkParamNames()
+	for _, vr := range v.routes {
+		v.checkRoute(vr)
+	}
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: v.issues}
+}
+
+// validateHost is the host of the example requests for the Routes that do not
+// depend on a host, it is reserved and therefore should match no host pattern.
+const validateHost = "validate.invalid"
+
+// validator holds the state of a Router.Validate call.
+type validator struct {
+	r      *Router
+	routes []*vroute
+	// The hosts field holds the example hosts of the Router's patterns that
+	// begin with a host, against which its non-host Routes are checked.
+	hosts []string
+	// The methods field holds the methods with which the Routes for any
+	// method are checked, i.e. the methods of the other Routes and GET.
+	methods []string
+	issues  []Issue
+	seen    map[string]bool // the reported issues
+}
+
+// vroute holds a Route together with its parsed pattern.
+type vroute struct {
+	rt   *Route
+	pat  *Pattern
+	segs []string // the segments of the pattern's path
+	// The example host of the Route's requests, empty if the Route
+	// was registered with the root Router without a host.
+	host string
+	// If set, the Route's pattern begins with a host.
+	hostPattern bool
+}
+
+// The collect method collects the Routes of the Router and its sub-routers.
+func (v *validator) collect() {
+	methods, hosts := map[string]bool{http.MethodGet: true}, map[string]bool{}
+	v.r.Walk(func(rt *Route) error {
+		vr := &vroute{rt: rt, pat: mustParsePattern(rt.Pattern)}
+		i := strings.IndexByte(rt.Pattern, '/')
+		if i == -1 {
+			// a host without a path can only be requested as "host/"
+			i = len(rt.Pattern)
+		}
+		if vr.segs = strings.Split(rt.Pattern[i:], "/")[1:]; i == len(rt.Pattern) {
+			vr.segs = []string{""}
+		}
+		if i > 0 {
+			vr.hostPattern = true
+			vr.host = placeholders(mustParsePattern(rt.Pattern[:i]).Parts)
+			if rt.Host == "" && !hosts[vr.host] {
+				hosts[vr.host] = true
+				v.hosts = append(v.hosts, vr.host)
+			}
+		} else if rt.Host != "" {
+			vr.host = placeholders(mustParsePattern(rt.Host).Parts)
+		}
+		for _, m := range rt.Methods {
+			if m != "*" {
+				methods[m] = true
+			}
+		}
+		v.routes = append(v.routes, vr)
+		return nil
+	})
+	for m := range methods {
+		v.methods = append(v.methods, m)
+	}
+	sort.Strings(v.methods)
+
+	// the Routes of the hosts claimed by a sub-router are reported as such,
+	// the Router's own Routes are not expected to handle those hosts
+	hs := v.hosts[:0]
+	for _, h := range v.hosts {
+		if _, sub := v.explain(http.MethodGet, h, "/"); sub == "" {
+			hs = append(hs, h)
+		}
+	}
+	v.hosts = hs
+}
+
+// The report method adds the issue unless an issue of the same
+// kind, with the same Route, method and other pattern, was added.
+func (v *validator) report(vr *vroute, kind IssueKind, method, other, path, format string, args ...interface{}) {
+	key := fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%s\x00%p", kind, vr.rt.Host, vr.rt.Pattern, method, other, vr.rt)
+	if v.seen[key] {
+		return
+	}
+	v.seen[key] = true
+	v.issues = append(v.issues, Issue{
+		Kind:    kind,
+		Host:    vr.rt.Host,
+		Pattern: vr.rt.Pattern,
+		Method:  method,
+		Other:   other,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// The checkParamNames method reports the patterns that differ from the first
+// pattern with the same shape, i.e. the same path with params in the same
+// places, in the names of their params.
+func (v *validator) checkParamNames() {
+	type group struct {
+		vr    *vroute
+		names string
+	}
+	first := make(map[string]group)
+	for _, vr := range v.routes {
+		parts := vr.pat.Parts
+		if vr.hostPattern {
+			if vr.pat.Path == "" {
+				continue // no path, no params to compare
+			}
+			parts = mustParsePattern(vr.pat.Path).Parts
+		}
+		key, names := shape(parts), paramNames(parts)
+		g, ok := first[key]
+		if !ok {
+			first[key] = group{vr, names}
+			continue
+		}
+		if names != g.names {
+			v.report(vr, IssueParamNames, strings.Join(vr.rt.Methods, ","), g.vr.rt.Pattern, "",
+				"the params are named %s while those of the pattern %q are named %s",
+				names, g.vr.rt.Pattern, g.names)
+		}
+	}
+}
+
+// The checkRoute method resolves the example requests of the Route and
+// reports the ones that do not reach the Route.
+func (v *validator) checkRoute(vr *vroute) {
+	methods := vr.rt.Methods
+	if len(methods) == 1 && methods[0] == "*" {
+		methods = v.methods
+	}
+	for _, path := range v.probes(vr) {
+		full := path
+		if vr.hostPattern {
+			full = vr.host + path
+		}
+		ps, ok := vr.pat.Match(full)
+		if !ok || (vr.rt.Rules != nil && vr.rt.validate(ps) != nil) {
+			continue
+		}
+
+		host := vr.host
+		if host == "" {
+			host = validateHost
+		}
+		for _, m := range methods {
+			if e, ok := v.resolve(vr, m, host, path); ok && vr.host == "" {
+				for _, h := range v.hosts {
+					v.resolveHost(vr, m, h, path, e)
+				}
+			}
+		}
+		v.checkLoop(vr, methods[0], host, path)
+	}
+}
+
+// The resolve method resolves a request for the Route and reports the issue if
+// the request does not reach it. The result reports whether the Route, or a
+// Route of the same node, handles the request or whether the pattern of another
+// Route that handles the request has a higher priority.
+func (v *validator) resolve(vr *vroute, method, host, path string) (*Explanation, bool) {
+	e, sub := v.explain(method, host, path)
+	if v.reached(vr, e, sub) {
+		return e, true
+	}
+	if sub != vr.rt.Host {
+		if sub != "" {
+			v.report(vr, IssueHostOverlap, method, sub, path, "the requests for the host %q are"+
+				" handled by the sub-router of the host pattern %q", host, sub)
+		} else {
+			v.report(vr, IssueHostOverlap, method, "", path, "the requests for the host %q are"+
+				" not handled by the sub-router", host)
+		}
+		return e, false
+	}
+
+	switch e.Status {
+	case http.StatusOK:
+		full := path
+		if e.Pattern[0] != '/' {
+			full = host + path
+		}
+		if _, ok := mustParsePattern(e.Pattern).Match(full); ok {
+			// the other pattern has a higher priority and
+			// a Route for the method, as one would expect
+			return e, true
+		}
+		v.report(vr, IssueUnreachable, method, e.Pattern, path, "the request for the path %q is"+
+			" resolved to the pattern %q which does not match it", path, e.Pattern)
+	case http.StatusMethodNotAllowed:
+		v.report(vr, IssueShadowed, method, e.Pattern, path, "the pattern %q has priority for the"+
+			" path %q but has no route for the method %s", e.Pattern, path, method)
+	case http.StatusMovedPermanently:
+		v.report(vr, IssueUnreachable, method, e.Pattern, path, "the request for the path %q"+
+			" is redirected to %q", path, e.Location)
+	default:
+		if e.Pattern != "" {
+			v.report(vr, IssueUnreachable, method, e.Pattern, path, "the path %q is matched by the pattern"+
+				" %q which has priority, but whose param rules reject it", path, e.Pattern)
+		} else if c, ok := vr.pat.CatchAll(); ok {
+			v.report(vr, IssueUnreachable, method, "", path, "the request for the path %q is not found, the"+
+				" catch-all *%s is unreachable since the lookup takes the edges of other patterns"+
+				" and does not backtrack", path, c.Value)
+		} else {
+			v.report(vr, IssueUnreachable, method, "", path, "the request for the path %q is not found,"+
+				" the lookup takes the edges of other patterns and does not backtrack", path)
+		}
+	}
+	return e, false
+}
+
+// The resolveHost method resolves a request for the non-host Route with the example
+// host of a pattern that begins with a host, and reports an overlap if the request
+// is resolved differently than the request base without such a host.
+func (v *validator) resolveHost(vr *vroute, method, host, path string, base *Explanation) {
+	e, sub := v.explain(method, host, path)
+	if sub == "" && e.Status == base.Status && e.Pattern == base.Pattern {
+		return
+	}
+	switch {
+	case sub != "":
+		v.report(vr, IssueHostOverlap, method, sub, path, "the requests for the host %q are"+
+			" handled by the sub-router of the host pattern %q", host, sub)
+	case e.Pattern != "":
+		v.report(vr, IssueHostOverlap, method, e.Pattern, host+path, "the request for the path %q"+
+			" with the host %q is resolved to the pattern %q", path, host, e.Pattern)
+	case e.Status == http.StatusMovedPermanently:
+		v.report(vr, IssueHostOverlap, method, "", host+path, "the request for the path %q with"+
+			" the host %q is redirected to %q", path, host, e.Location)
+	default:
+		v.report(vr, IssueHostOverlap, method, "", host+path, "the request for the path %q with"+
+			" the host %q is not found", path, host)
+	}
+}
+
+// The reached method reports whether the request resolved to e, by the sub-router
+// with the host pattern sub, reached the Route or another Route of its node.
+func (v *validator) reached(vr *vroute, e *Explanation, sub string) bool {
+	if e.Route == vr.rt {
+		return true
+	}
+	return sub == vr.rt.Host && e.Pattern != "" &&
+		shape(mustParsePattern(e.Pattern).Parts) == shape(vr.pat.Parts)
+}
+
+// The checkLoop method follows the trailing slash redirects of the request
+// for the path, and of the path with its trailing slash toggled, and reports
+// the redirects that loop.
+func (v *validator) checkLoop(vr *vroute, method, host, path string) {
+	alt := path + "/"
+	if strings.HasSuffix(path, "/") {
+		alt = path[:len(path)-1]
+	}
+	for _, p := range []string{path, alt} {
+		if p == "" {
+			continue
+		}
+		chain := []string{p}
+		seen := map[string]bool{p: true}
+		for i := 0; i < 8; i++ {
+			e, _ := v.explain(method, host, p)
+			if e.Status != http.StatusMovedPermanently {
+				break
+			}
+			p = e.Location
+			chain = append(chain, p)
+			if seen[p] {
+				v.report(vr, IssueRedirectLoop, method, "", chain[0], "the requests"+
+					" are redirected in a loop: %s", strings.Join(chain, " -> "))
+				break
+			}
+			seen[p] = true
+		}
+	}
+}
+
+// The explain method resolves a request the way Router.Explain does and
+// returns the decision together with the host pattern of the sub-router
+// that resolved the request, empty if the Router itself did.
+func (v *validator) explain(method, host, path string) (*Explanation, string) {
+	var sub string
+	v.r.mu.RLock()
+	if v.r.vhosts != nil {
+		if s, _ := v.r.vhost(host, nil, nil); s != nil {
+			sub = s.host
+		}
+	}
+	v.r.mu.RUnlock()
+
+	return v.r.explain(method, host, path, &trace{discard: true}), sub
+}
+
+// The probes method returns the paths of the example requests for the Route,
+// i.e. its path with placeholders for the params, and the paths that follow the
+// static segments of the other patterns of the same Router in place of the params.
+func (v *validator) probes(vr *vroute) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	add(probePath(vr.segs, nil))
+	if vr.pat.IsStatic() {
+		return paths // the path of a static pattern is the only one
+	}
+	if last := vr.segs[len(vr.segs)-1]; segmentKind(last) == CatchAllPart {
+		// a catch-all captures multiple segments as well
+		add(probePath(vr.segs, nil) + "/_")
+	}
+	for _, o := range v.routes {
+		if o.rt.Host != vr.rt.Host || o.rt.Pattern == vr.rt.Pattern {
+			continue
+		}
+		add(probePath(vr.segs, o.segs))
+	}
+	return paths
+}
+
+// probePath returns the path of segs with placeholders for the params, or with
+// the static segments of other, if not nil, in place of the params.
+func probePath(segs, other []string) string {
+	var sb strings.Builder
+	for i, s := range segs {
+		sb.WriteByte('/')
+		switch segmentKind(s) {
+		case ParamPart:
+			if i < len(other) && segmentKind(other[i]) == StaticPart && other[i] != "" {
+				sb.WriteString(other[i])
+				continue
+			}
+		case CatchAllPart:
+			if i < len(other) {
+				rest := make([]string, len(other)-i)
+				for j, o := range other[i:] {
+					rest[j] = placeholders(segmentParts(o))
+				}
+				if p := strings.Join(rest, "/"); p != "" {
+					sb.WriteString(p)
+					continue
+				}
+			}
+		}
+		sb.WriteString(placeholders(segmentParts(s)))
+	}
+	return sb.String()
+}
+
+// segmentKind returns the kind of the segment of a pattern, or -1 if
+// the segment mixes static text and params.
+func segmentKind(s string) PartKind {
+	switch parts := segmentParts(s); {
+	case len(parts) == 0:
+		return StaticPart
+	case len(parts) == 1:
+		return parts[0].Kind
+	}
+	return -1
+}
+
+// segmentParts returns the parts of the segment of a pattern, a segment
+// that is not a valid pattern on its own, e.g. the "{a" of "{a/b}", is
+// returned as static text.
+func segmentParts(s string) []Part {
+	p, err := ParsePattern(s)
+	if err != nil {
+		if s == "" {
+			return nil
+		}
+		return []Part{{Kind: StaticPart, Value: s}}
+	}
+	return p.Parts
+}
+
+// mustParsePattern parses a pattern that was registered with the
+// Router, and is therefore valid, or a part of such a pattern.
+func mustParsePattern(pattern string) *Pattern {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// placeholders returns the parts of a pattern with the params and
+// catch-alls replaced by placeholder values, e.g. "_id" for "{id}".
+func placeholders(parts []Part) string {
+	var sb strings.Builder
+	for _, pt := range parts {
+		if pt.Kind != StaticPart {
+			sb.WriteByte('_')
+		}
+		sb.WriteString(pt.Value)
+	}
+	return sb.String()
+}
+
+// shape returns the parts of a pattern without the names of the params
+// and catch-alls, e.g. "/users/{}/files/*" for "/users/{id}/files/*path".
+func shape(parts []Part) string {
+	var sb strings.Builder
+	for _, pt := range parts {
+		switch pt.Kind {
+		case StaticPart:
+			sb.WriteString(pt.Value)
+		case ParamPart:
+			sb.WriteString("{}")
+		case CatchAllPart:
+			sb.WriteString("*")
+		}
+	}
+	return sb.String()
+}
+
+// paramNames returns the names of the params and catch-alls of the
+// parts of a pattern, e.g. "{id}, *path".
+func paramNames(parts []Part) string {
+	var names []string
+	for _, pt := range parts {
+		switch pt.Kind {
+		case ParamPart:
+			names = append(names, "{"+pt.Value+"}")
+		case CatchAllPart:
+			names = append(names, "*"+pt.Value)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,135 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRouterValidate(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		setup routerSetup
+		hosts routerSetup // the routes of the sub-router of the host "api.example.com"
+		want  []string
+	}{{
+		setup: routerSetup{
+			{"GET", "/users", "h"},
+			{"GET", "/users/{id}", "h"},
+			{"PUT,DELETE", "/users/{id}", "h"},
+			{"GET", "/users/{id}/posts", "h"},
+			{"GET", "/users/me", "h"},
+			{"PUT", "/users/me", "h"},
+			{"DELETE", "/users/me", "h"},
+			{"GET", "/static/*file", "h"},
+		},
+		want: nil,
+	}, {
+		// the static pattern has no route for POST
+		setup: routerSetup{
+			{"GET", "/a/new", "h"},
+			{"POST", "/a/{b}", "h"},
+		},
+		want: []string{`shadowed POST "/a/{b}" "/a/new"`},
+	}, {
+		// the lookup descends into "/x/" and does not backtrack to "/{a}/"
+		setup: routerSetup{
+			{"GET", "/{a}/b/c", "h"},
+			{"GET", "/x/{d}/e", "h"},
+		},
+		want: []string{`unreachable GET "/{a}/b/c" ""`},
+	}, {
+		// the param takes the first segment of the catch-all's paths
+		setup: routerSetup{
+			{"GET", "/files/{name}", "h"},
+			{"GET", "/files/*path", "h"},
+		},
+		want: []string{`unreachable GET "/files/*path" ""`},
+	}, {
+		setup: routerSetup{
+			{"GET", "/u/{id}", "h"},
+			{"GET", "example.com/u/{uid}", "h"},
+			{"GET", "/v", "h"},
+			{"GET", "{t}.example.com/v/", "h"},
+			{"GET", "api.example.com/y", "h"},
+		},
+		hosts: routerSetup{
+			{"GET", "/y", "h"},
+		},
+		want: []string{
+			`param names GET "example.com/u/{uid}" "/u/{id}"`,
+			`host overlap GET "/u/{id}" "example.com/u/{uid}"`,
+			`host overlap GET "/v" ""`,
+			`host overlap GET "api.example.com/y" "api.example.com"`,
+		},
+	}, {
+		setup: routerSetup{
+			{"GET", "example.com/a/*p", "h"},
+			{"GET", "example.com/{x}/", "h"},
+			{"GET", "/a", "h"},
+		},
+		want: []string{
+			`unreachable GET "example.com/{x}/" ""`,
+			`redirect loop GET "example.com/{x}/" ""`,
+			`host overlap GET "/a" ""`,
+		},
+	}, {
+		// the catch-all's name is that of the last registered pattern
+		setup: routerSetup{
+			{"GET", "/s/*path", "h"},
+			{"POST", "/s/{file...}", "h"},
+		},
+		want: []string{`param names POST "/s/{file...}" "/s/*path"`},
+	}, {
+		// the patterns without a path have no path params to compare
+		setup: routerSetup{
+			{"GET", "{sub}.example.com", "h"},
+			{"GET", "www.{name}.example.org", "h"},
+			{"GET", "{sub}.example.com/u/{id}", "h"},
+			{"GET", "www.{name}.example.org/u/{uid}", "h"},
+		},
+		want: []string{`param names GET "{sub}.example.com/u/{id}" "www.{name}.example.org/u/{uid}"`},
+	}}
+
+	for i, tt := range tests {
+		router := tt.setup.Router()
+		if tt.hosts != nil {
+			sub := router.Host("api.example.com")
+			for _, s := range tt.hosts {
+				sub.Handle(s.method, s.pattern, s.handler)
+			}
+		}
+
+		var got []string
+		var ve *ValidationError
+		if err := router.Validate(); errors.As(err, &ve) {
+			for _, is := range ve.Issues {
+				got = append(got, fmt.Sprintf("%s %s %q %q", is.Kind, is.Method, is.Pattern, is.Other))
+			}
+		} else if err != nil {
+			t.Errorf("#%d: unexpected error %v", i, err)
+		}
+		equals(t, i, got, tt.want)
+	}
+}
+
+func TestRouterValidate_Rules(t *testing.T) {
+	//t.Skip()
+	router := NewRouter()
+	router.Handle("GET", "/files/{id}", strHandler("h")).WithRules("id", Range(1, 100))
+	router.Handle("GET", "/files/*path", strHandler("h"))
+	router.Handle("GET", "/pages/{n}", strHandler("h")).WithRules("n", OneOf("about"))
+	router.Handle("GET", "/pages/index", strHandler("h"))
+
+	var got []string
+	var ve *ValidationError
+	if errors.As(router.Validate(), &ve) {
+		for _, is := range ve.Issues {
+			got = append(got, fmt.Sprintf("%s %s %q %q %q", is.Kind, is.Method, is.Pattern, is.Other, is.Path))
+		}
+	}
+	equals(t, 0, got, []string{
+		`unreachable GET "/files/*path" "/files/{id}" "/files/_path"`,
+		`unreachable GET "/files/*path" "" "/files/_path/_"`,
+	})
+}
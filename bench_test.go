@@ -0,0 +1,681 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type benchRoute struct {
+	method string
+	path   string
+}
+
+// staticRoutes is modeled on the static file tree of the Go website.
+var staticRoutes = []benchRoute{
+	{"GET", "/"},
+	{"GET", "/cmd.html"},
+	{"GET", "/code.html"},
+	{"GET", "/contrib.html"},
+	{"GET", "/contribute.html"},
+	{"GET", "/debugging_with_gdb.html"},
+	{"GET", "/docs.html"},
+	{"GET", "/effective_go.html"},
+	{"GET", "/files.log"},
+	{"GET", "/gccgo_contribute.html"},
+	{"GET", "/gccgo_install.html"},
+	{"GET", "/go-logo-black.png"},
+	{"GET", "/go-logo-blue.png"},
+	{"GET", "/go-logo-white.png"},
+	{"GET", "/go1.1.html"},
+	{"GET", "/go1.2.html"},
+	{"GET", "/go1.html"},
+	{"GET", "/go1compat.html"},
+	{"GET", "/go_faq.html"},
+	{"GET", "/go_mem.html"},
+	{"GET", "/go_spec.html"},
+	{"GET", "/help.html"},
+	{"GET", "/ie.css"},
+	{"GET", "/install-source.html"},
+	{"GET", "/install.html"},
+	{"GET", "/logo-153x55.png"},
+	{"GET", "/Makefile"},
+	{"GET", "/root.html"},
+	{"GET", "/share.png"},
+	{"GET", "/sieve.gif"},
+	{"GET", "/tos.html"},
+	{"GET", "/articles/"},
+	{"GET", "/articles/go_command.html"},
+	{"GET", "/articles/index.html"},
+	{"GET", "/articles/wiki/"},
+	{"GET", "/articles/wiki/edit.html"},
+	{"GET", "/articles/wiki/final-noclosure.go"},
+	{"GET", "/articles/wiki/final-noerror.go"},
+	{"GET", "/articles/wiki/final-parsetemplate.go"},
+	{"GET", "/articles/wiki/final-template.go"},
+	{"GET", "/articles/wiki/final.go"},
+	{"GET", "/articles/wiki/get.go"},
+	{"GET", "/articles/wiki/http-sample.go"},
+	{"GET", "/articles/wiki/index.html"},
+	{"GET", "/articles/wiki/Makefile"},
+	{"GET", "/articles/wiki/notemplate.go"},
+	{"GET", "/articles/wiki/part1-noerror.go"},
+	{"GET", "/articles/wiki/part1.go"},
+	{"GET", "/articles/wiki/part2.go"},
+	{"GET", "/articles/wiki/part3-errorhandling.go"},
+	{"GET", "/articles/wiki/part3.go"},
+	{"GET", "/articles/wiki/test.bash"},
+	{"GET", "/articles/wiki/test_edit.good"},
+	{"GET", "/articles/wiki/test_Test.txt.good"},
+	{"GET", "/articles/wiki/test_view.good"},
+	{"GET", "/articles/wiki/view.html"},
+	{"GET", "/codewalk/"},
+	{"GET", "/codewalk/codewalk.css"},
+	{"GET", "/codewalk/codewalk.js"},
+	{"GET", "/codewalk/codewalk.xml"},
+	{"GET", "/codewalk/functions.xml"},
+	{"GET", "/codewalk/markov.go"},
+	{"GET", "/codewalk/markov.xml"},
+	{"GET", "/codewalk/pig.go"},
+	{"GET", "/codewalk/popout.png"},
+	{"GET", "/codewalk/run"},
+	{"GET", "/codewalk/sharemem.xml"},
+	{"GET", "/codewalk/urlpoll.go"},
+	{"GET", "/devel/"},
+	{"GET", "/devel/release.html"},
+	{"GET", "/devel/weekly.html"},
+	{"GET", "/gopher/"},
+	{"GET", "/gopher/appenginegopher.jpg"},
+	{"GET", "/gopher/appenginegophercolor.jpg"},
+	{"GET", "/gopher/appenginelogo.gif"},
+	{"GET", "/gopher/bumper.png"},
+	{"GET", "/gopher/doc.png"},
+	{"GET", "/gopher/frontpage.png"},
+	{"GET", "/gopher/gopherbw.png"},
+	{"GET", "/gopher/gophercolor.png"},
+	{"GET", "/gopher/gophercolor16x16.png"},
+	{"GET", "/gopher/help.png"},
+	{"GET", "/gopher/pkg.png"},
+	{"GET", "/gopher/project.png"},
+	{"GET", "/gopher/ref.png"},
+	{"GET", "/gopher/run.png"},
+	{"GET", "/gopher/talks.png"},
+	{"GET", "/gopher/pencil/"},
+	{"GET", "/gopher/pencil/gopherhat.jpg"},
+	{"GET", "/gopher/pencil/gopherhelmet.jpg"},
+	{"GET", "/gopher/pencil/gophermega.jpg"},
+	{"GET", "/gopher/pencil/gopherrunning.jpg"},
+	{"GET", "/gopher/pencil/gopherswim.jpg"},
+	{"GET", "/gopher/pencil/gopherswrench.jpg"},
+	{"GET", "/play/"},
+	{"GET", "/play/fib.go"},
+	{"GET", "/play/hello.go"},
+	{"GET", "/play/life.go"},
+	{"GET", "/play/peano.go"},
+	{"GET", "/play/pi.go"},
+	{"GET", "/play/sieve.go"},
+	{"GET", "/play/solitaire.go"},
+	{"GET", "/play/tree.go"},
+	{"GET", "/progs/"},
+	{"GET", "/progs/cgo1.go"},
+	{"GET", "/progs/cgo2.go"},
+	{"GET", "/progs/cgo3.go"},
+	{"GET", "/progs/cgo4.go"},
+	{"GET", "/progs/defer.go"},
+	{"GET", "/progs/defer.out"},
+	{"GET", "/progs/defer2.go"},
+	{"GET", "/progs/defer2.out"},
+	{"GET", "/progs/eff_bytesize.go"},
+	{"GET", "/progs/eff_bytesize.out"},
+	{"GET", "/progs/eff_qr.go"},
+	{"GET", "/progs/eff_sequence.go"},
+	{"GET", "/progs/eff_sequence.out"},
+	{"GET", "/progs/error.go"},
+	{"GET", "/progs/error2.go"},
+	{"GET", "/progs/error3.go"},
+	{"GET", "/progs/error4.go"},
+	{"GET", "/progs/go1.go"},
+	{"GET", "/progs/gobs1.go"},
+	{"GET", "/progs/gobs2.go"},
+	{"GET", "/progs/image_draw.go"},
+	{"GET", "/progs/image_package1.go"},
+	{"GET", "/progs/interface.go"},
+	{"GET", "/progs/interface2.go"},
+	{"GET", "/progs/json1.go"},
+	{"GET", "/progs/run"},
+	{"GET", "/progs/slices.go"},
+	{"GET", "/progs/timeout1.go"},
+	{"GET", "/progs/update.bash"},
+}
+
+// githubRoutes is modeled on the GitHub REST API.
+var githubRoutes = []benchRoute{
+	// OAuth Authorizations
+	{"GET", "/authorizations"},
+	{"GET", "/authorizations/{id}"},
+	{"POST", "/authorizations"},
+	{"DELETE", "/authorizations/{id}"},
+	{"GET", "/applications/{client_id}/tokens/{access_token}"},
+	{"DELETE", "/applications/{client_id}/tokens"},
+	{"DELETE", "/applications/{client_id}/tokens/{access_token}"},
+
+	// Activity
+	{"GET", "/events"},
+	{"GET", "/repos/{owner}/{repo}/events"},
+	{"GET", "/networks/{owner}/{repo}/events"},
+	{"GET", "/orgs/{org}/events"},
+	{"GET", "/users/{user}/received_events"},
+	{"GET", "/users/{user}/received_events/public"},
+	{"GET", "/users/{user}/events"},
+	{"GET", "/users/{user}/events/public"},
+	{"GET", "/users/{user}/events/orgs/{org}"},
+	{"GET", "/feeds"},
+	{"GET", "/notifications"},
+	{"GET", "/repos/{owner}/{repo}/notifications"},
+	{"PUT", "/notifications"},
+	{"PUT", "/repos/{owner}/{repo}/notifications"},
+	{"GET", "/notifications/threads/{id}"},
+	{"GET", "/notifications/threads/{id}/subscription"},
+	{"PUT", "/notifications/threads/{id}/subscription"},
+	{"DELETE", "/notifications/threads/{id}/subscription"},
+	{"GET", "/repos/{owner}/{repo}/stargazers"},
+	{"GET", "/users/{user}/starred"},
+	{"GET", "/user/starred"},
+	{"GET", "/user/starred/{owner}/{repo}"},
+	{"PUT", "/user/starred/{owner}/{repo}"},
+	{"DELETE", "/user/starred/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/subscribers"},
+	{"GET", "/users/{user}/subscriptions"},
+	{"GET", "/user/subscriptions"},
+	{"GET", "/repos/{owner}/{repo}/subscription"},
+	{"PUT", "/repos/{owner}/{repo}/subscription"},
+	{"DELETE", "/repos/{owner}/{repo}/subscription"},
+	{"GET", "/user/subscriptions/{owner}/{repo}"},
+	{"PUT", "/user/subscriptions/{owner}/{repo}"},
+	{"DELETE", "/user/subscriptions/{owner}/{repo}"},
+
+	// Gists
+	{"GET", "/users/{user}/gists"},
+	{"GET", "/gists"},
+	{"GET", "/gists/public"},
+	{"GET", "/gists/starred"},
+	{"GET", "/gists/{id}"},
+	{"POST", "/gists"},
+	{"PATCH", "/gists/{id}"},
+	{"PUT", "/gists/{id}/star"},
+	{"DELETE", "/gists/{id}/star"},
+	{"GET", "/gists/{id}/star"},
+	{"POST", "/gists/{id}/forks"},
+	{"DELETE", "/gists/{id}"},
+
+	// Git Data
+	{"GET", "/repos/{owner}/{repo}/git/blobs/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/blobs"},
+	{"GET", "/repos/{owner}/{repo}/git/commits/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/commits"},
+	{"GET", "/repos/{owner}/{repo}/git/refs/*ref"},
+	{"POST", "/repos/{owner}/{repo}/git/refs"},
+	{"GET", "/repos/{owner}/{repo}/git/tags/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/tags"},
+	{"GET", "/repos/{owner}/{repo}/git/trees/{sha}"},
+	{"POST", "/repos/{owner}/{repo}/git/trees"},
+
+	// Issues
+	{"GET", "/issues"},
+	{"GET", "/user/issues"},
+	{"GET", "/orgs/{org}/issues"},
+	{"GET", "/repos/{owner}/{repo}/issues"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}"},
+	{"POST", "/repos/{owner}/{repo}/issues"},
+	{"PATCH", "/repos/{owner}/{repo}/issues/{number}"},
+	{"GET", "/repos/{owner}/{repo}/assignees"},
+	{"GET", "/repos/{owner}/{repo}/assignees/{assignee}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/comments"},
+	{"GET", "/repos/{owner}/{repo}/issues/comments"},
+	{"GET", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"POST", "/repos/{owner}/{repo}/issues/{number}/comments"},
+	{"PATCH", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/comments/{id}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/events"},
+	{"GET", "/repos/{owner}/{repo}/issues/events"},
+	{"GET", "/repos/{owner}/{repo}/issues/events/{id}"},
+	{"GET", "/repos/{owner}/{repo}/labels"},
+	{"GET", "/repos/{owner}/{repo}/labels/{name}"},
+	{"POST", "/repos/{owner}/{repo}/labels"},
+	{"PATCH", "/repos/{owner}/{repo}/labels/{name}"},
+	{"DELETE", "/repos/{owner}/{repo}/labels/{name}"},
+	{"GET", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"POST", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/{number}/labels/{name}"},
+	{"PUT", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"DELETE", "/repos/{owner}/{repo}/issues/{number}/labels"},
+	{"GET", "/repos/{owner}/{repo}/milestones/{number}/labels"},
+	{"GET", "/repos/{owner}/{repo}/milestones"},
+	{"GET", "/repos/{owner}/{repo}/milestones/{number}"},
+	{"POST", "/repos/{owner}/{repo}/milestones"},
+	{"PATCH", "/repos/{owner}/{repo}/milestones/{number}"},
+	{"DELETE", "/repos/{owner}/{repo}/milestones/{number}"},
+
+	// Miscellaneous
+	{"GET", "/emojis"},
+	{"GET", "/gitignore/templates"},
+	{"GET", "/gitignore/templates/{name}"},
+	{"POST", "/markdown"},
+	{"POST", "/markdown/raw"},
+	{"GET", "/meta"},
+	{"GET", "/rate_limit"},
+
+	// Organizations
+	{"GET", "/users/{user}/orgs"},
+	{"GET", "/user/orgs"},
+	{"GET", "/orgs/{org}"},
+	{"PATCH", "/orgs/{org}"},
+	{"GET", "/orgs/{org}/members"},
+	{"GET", "/orgs/{org}/members/{user}"},
+	{"DELETE", "/orgs/{org}/members/{user}"},
+	{"GET", "/orgs/{org}/public_members"},
+	{"GET", "/orgs/{org}/public_members/{user}"},
+	{"PUT", "/orgs/{org}/public_members/{user}"},
+	{"DELETE", "/orgs/{org}/public_members/{user}"},
+	{"GET", "/orgs/{org}/teams"},
+	{"GET", "/teams/{id}"},
+	{"POST", "/orgs/{org}/teams"},
+	{"PATCH", "/teams/{id}"},
+	{"DELETE", "/teams/{id}"},
+	{"GET", "/teams/{id}/members"},
+	{"GET", "/teams/{id}/members/{user}"},
+	{"PUT", "/teams/{id}/members/{user}"},
+	{"DELETE", "/teams/{id}/members/{user}"},
+	{"GET", "/teams/{id}/repos"},
+	{"GET", "/teams/{id}/repos/{owner}/{repo}"},
+	{"PUT", "/teams/{id}/repos/{owner}/{repo}"},
+	{"DELETE", "/teams/{id}/repos/{owner}/{repo}"},
+	{"GET", "/user/teams"},
+
+	// Pull Requests
+	{"GET", "/repos/{owner}/{repo}/pulls"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}"},
+	{"POST", "/repos/{owner}/{repo}/pulls"},
+	{"PATCH", "/repos/{owner}/{repo}/pulls/{number}"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/commits"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/files"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/merge"},
+	{"PUT", "/repos/{owner}/{repo}/pulls/{number}/merge"},
+	{"GET", "/repos/{owner}/{repo}/pulls/{number}/comments"},
+	{"GET", "/repos/{owner}/{repo}/pulls/comments"},
+	{"GET", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+	{"PUT", "/repos/{owner}/{repo}/pulls/{number}/comments"},
+	{"PATCH", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+	{"DELETE", "/repos/{owner}/{repo}/pulls/comments/{number}"},
+
+	// Repositories
+	{"GET", "/user/repos"},
+	{"GET", "/users/{user}/repos"},
+	{"GET", "/orgs/{org}/repos"},
+	{"GET", "/repositories"},
+	{"POST", "/user/repos"},
+	{"POST", "/orgs/{org}/repos"},
+	{"GET", "/repos/{owner}/{repo}"},
+	{"PATCH", "/repos/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/contributors"},
+	{"GET", "/repos/{owner}/{repo}/languages"},
+	{"GET", "/repos/{owner}/{repo}/teams"},
+	{"GET", "/repos/{owner}/{repo}/tags"},
+	{"GET", "/repos/{owner}/{repo}/branches"},
+	{"GET", "/repos/{owner}/{repo}/branches/{branch}"},
+	{"DELETE", "/repos/{owner}/{repo}"},
+	{"GET", "/repos/{owner}/{repo}/collaborators"},
+	{"GET", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"PUT", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"DELETE", "/repos/{owner}/{repo}/collaborators/{user}"},
+	{"GET", "/repos/{owner}/{repo}/comments"},
+	{"GET", "/repos/{owner}/{repo}/commits/{sha}/comments"},
+	{"POST", "/repos/{owner}/{repo}/commits/{sha}/comments"},
+	{"GET", "/repos/{owner}/{repo}/comments/{id}"},
+	{"PATCH", "/repos/{owner}/{repo}/comments/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/comments/{id}"},
+	{"GET", "/repos/{owner}/{repo}/commits"},
+	{"GET", "/repos/{owner}/{repo}/commits/{sha}"},
+	{"GET", "/repos/{owner}/{repo}/readme"},
+	{"GET", "/repos/{owner}/{repo}/contents/*path"},
+	{"PUT", "/repos/{owner}/{repo}/contents/*path"},
+	{"DELETE", "/repos/{owner}/{repo}/contents/*path"},
+	{"GET", "/repos/{owner}/{repo}/{archive_format}/{ref}"},
+	{"GET", "/repos/{owner}/{repo}/keys"},
+	{"GET", "/repos/{owner}/{repo}/keys/{id}"},
+	{"POST", "/repos/{owner}/{repo}/keys"},
+	{"PATCH", "/repos/{owner}/{repo}/keys/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/keys/{id}"},
+	{"GET", "/repos/{owner}/{repo}/downloads"},
+	{"GET", "/repos/{owner}/{repo}/downloads/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/downloads/{id}"},
+	{"GET", "/repos/{owner}/{repo}/forks"},
+	{"POST", "/repos/{owner}/{repo}/forks"},
+	{"GET", "/repos/{owner}/{repo}/hooks"},
+	{"GET", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/hooks"},
+	{"PATCH", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/hooks/{id}/tests"},
+	{"DELETE", "/repos/{owner}/{repo}/hooks/{id}"},
+	{"POST", "/repos/{owner}/{repo}/merges"},
+	{"GET", "/repos/{owner}/{repo}/releases"},
+	{"GET", "/repos/{owner}/{repo}/releases/{id}"},
+	{"POST", "/repos/{owner}/{repo}/releases"},
+	{"PATCH", "/repos/{owner}/{repo}/releases/{id}"},
+	{"DELETE", "/repos/{owner}/{repo}/releases/{id}"},
+	{"GET", "/repos/{owner}/{repo}/releases/{id}/assets"},
+	{"GET", "/repos/{owner}/{repo}/stats/contributors"},
+	{"GET", "/repos/{owner}/{repo}/stats/commit_activity"},
+	{"GET", "/repos/{owner}/{repo}/stats/code_frequency"},
+	{"GET", "/repos/{owner}/{repo}/stats/participation"},
+	{"GET", "/repos/{owner}/{repo}/stats/punch_card"},
+	{"GET", "/repos/{owner}/{repo}/statuses/{ref}"},
+	{"POST", "/repos/{owner}/{repo}/statuses/{ref}"},
+
+	// Search
+	{"GET", "/search/repositories"},
+	{"GET", "/search/code"},
+	{"GET", "/search/issues"},
+	{"GET", "/search/users"},
+	{"GET", "/legacy/issues/search/{owner}/{repository}/{state}/{keyword}"},
+	{"GET", "/legacy/repos/search/{keyword}"},
+	{"GET", "/legacy/user/search/{keyword}"},
+	{"GET", "/legacy/user/email/{email}"},
+
+	// Users
+	{"GET", "/users/{user}"},
+	{"GET", "/user"},
+	{"PATCH", "/user"},
+	{"GET", "/users"},
+	{"GET", "/user/emails"},
+	{"POST", "/user/emails"},
+	{"DELETE", "/user/emails"},
+	{"GET", "/users/{user}/followers"},
+	{"GET", "/user/followers"},
+	{"GET", "/users/{user}/following"},
+	{"GET", "/user/following"},
+	{"GET", "/user/following/{user}"},
+	{"GET", "/users/{user}/following/{target_user}"},
+	{"PUT", "/user/following/{user}"},
+	{"DELETE", "/user/following/{user}"},
+	{"GET", "/users/{user}/keys"},
+	{"GET", "/user/keys"},
+	{"GET", "/user/keys/{id}"},
+	{"POST", "/user/keys"},
+	{"PATCH", "/user/keys/{id}"},
+	{"DELETE", "/user/keys/{id}"},
+}
+
+// parseRoutes is modeled on the Parse REST API.
+var parseRoutes = []benchRoute{
+	// Objects
+	{"POST", "/1/classes/{className}"},
+	{"GET", "/1/classes/{className}/{objectId}"},
+	{"PUT", "/1/classes/{className}/{objectId}"},
+	{"GET", "/1/classes/{className}"},
+	{"DELETE", "/1/classes/{className}/{objectId}"},
+
+	// Users
+	{"POST", "/1/users"},
+	{"GET", "/1/login"},
+	{"GET", "/1/users/{objectId}"},
+	{"PUT", "/1/users/{objectId}"},
+	{"GET", "/1/users"},
+	{"DELETE", "/1/users/{objectId}"},
+	{"POST", "/1/requestPasswordReset"},
+
+	// Roles
+	{"POST", "/1/roles"},
+	{"GET", "/1/roles/{objectId}"},
+	{"PUT", "/1/roles/{objectId}"},
+	{"GET", "/1/roles"},
+	{"DELETE", "/1/roles/{objectId}"},
+
+	// Files
+	{"POST", "/1/files/{fileName}"},
+
+	// Analytics
+	{"POST", "/1/events/{eventName}"},
+
+	// Push Notifications
+	{"POST", "/1/push"},
+
+	// Installations
+	{"POST", "/1/installations"},
+	{"GET", "/1/installations/{objectId}"},
+	{"PUT", "/1/installations/{objectId}"},
+	{"GET", "/1/installations"},
+	{"DELETE", "/1/installations/{objectId}"},
+
+	// Cloud Functions
+	{"POST", "/1/functions"},
+}
+
+// benchPath returns a request path for the given pattern by substituting
+// each param and catch-all with a sample value.
+func benchPath(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '{':
+			j := strings.IndexByte(pattern[i:], '}')
+			b.WriteString("value")
+			i += j
+		case '*':
+			b.WriteString("some/file.txt")
+			i = len(pattern)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+type nopHandler struct{}
+
+func (nopHandler) ServeHTTP(context.Context, http.ResponseWriter, *http.Request) {}
+
+// nopWriter is a http.ResponseWriter that does not allocate.
+type nopWriter struct {
+	h http.Header
+}
+
+func (w *nopWriter) Header() http.Header         { return w.h }
+func (w *nopWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *nopWriter) WriteHeader(int)             {}
+
+func benchRouter(routes []benchRoute) *Router {
+	r := NewRouter()
+	for _, rt := range routes {
+		r.Handle(rt.method, rt.path, nopHandler{})
+	}
+	return r
+}
+
+func benchRequests(routes []benchRoute) []*http.Request {
+	reqs := make([]*http.Request, len(routes))
+	for i, rt := range routes {
+		reqs[i] = mustNewRequest(rt.method, benchPath(rt.path), nil)
+		reqs[i].RequestURI = reqs[i].URL.RequestURI()
+	}
+	return reqs
+}
+
+func benchServe(b *testing.B, router *Router, reqs []*http.Request) {
+	w := &nopWriter{h: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range reqs {
+			router.ServeHTTP(w, r)
+		}
+	}
+}
+
+func BenchmarkStatic_All(b *testing.B) {
+	benchServe(b, benchRouter(staticRoutes), benchRequests(staticRoutes))
+}
+
+func BenchmarkGitHub_All(b *testing.B) {
+	benchServe(b, benchRouter(githubRoutes), benchRequests(githubRoutes))
+}
+
+func BenchmarkGitHub_Static(b *testing.B) {
+	benchServe(b, benchRouter(githubRoutes), benchRequests([]benchRoute{{"GET", "/user/repos"}}))
+}
+
+func BenchmarkGitHub_Param(b *testing.B) {
+	benchServe(b, benchRouter(githubRoutes), benchRequests([]benchRoute{{"GET", "/repos/{owner}/{repo}/pulls/{number}/comments"}}))
+}
+
+func BenchmarkGitHub_AllFrozen(b *testing.B) {
+	router := benchRouter(githubRoutes)
+	router.Freeze()
+	benchServe(b, router, benchRequests(githubRoutes))
+}
+
+func BenchmarkTree_GitHub(b *testing.B) {
+	tree := NewTree[string]('/')
+	paths := make([]string, len(githubRoutes))
+	for i, rt := range githubRoutes {
+		tree.Insert(rt.path, rt.path)
+		paths[i] = benchPath(rt.path)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			tree.Lookup(p)
+		}
+	}
+}
+
+func BenchmarkNormalizer_GitHub(b *testing.B) {
+	n := benchRouter(githubRoutes).Normalizer()
+	urls := make([]*url.URL, len(githubRoutes))
+	for i, rt := range githubRoutes {
+		urls[i] = &url.URL{Path: benchPath(rt.path)}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, u := range urls {
+			n.NormalizeURL(u)
+		}
+	}
+}
+
+func BenchmarkParse_All(b *testing.B) {
+	benchServe(b, benchRouter(parseRoutes), benchRequests(parseRoutes))
+}
+
+func BenchmarkParse_Param(b *testing.B) {
+	benchServe(b, benchRouter(parseRoutes), benchRequests([]benchRoute{{"GET", "/1/classes/{className}/{objectId}"}}))
+}
+
+// scaleSizes are the numbers of routes used by the Scale benchmarks. The costs
+// grow sublinearly but they do grow: from 1k to 300k routes the per-route cost
+// of Handle grows about 2-4 times, and that of a lookup about 4-8 times, mostly
+// with the depth of the tree and the cache misses of the larger tree.
+var scaleSizes = []int{1000, 10000, 100000, 300000}
+
+// scaleRoutes returns n distinct static patterns modeled on the pages of a CMS.
+func scaleRoutes(n int) []string {
+	sections := []string{"blog", "docs", "news", "help", "about", "careers", "press", "events"}
+	words := []string{"go", "router", "release", "guide", "intro", "deep", "dive", "notes",
+		"update", "event", "team", "product", "design", "api", "cloud", "security"}
+
+	rnd := rand.New(rand.NewSource(int64(n)))
+	patterns := make([]string, n)
+	for i := range patterns {
+		patterns[i] = "/" + sections[rnd.Intn(len(sections))] +
+			"/" + strconv.Itoa(2000+rnd.Intn(25)) +
+			"/" + words[rnd.Intn(len(words))] + "-" + words[rnd.Intn(len(words))] +
+			"-" + strconv.Itoa(i)
+	}
+	return patterns
+}
+
+func BenchmarkScale_Handle(b *testing.B) {
+	for _, n := range scaleSizes {
+		patterns := scaleRoutes(n)
+		b.Run(fmt.Sprintf("routes=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				r := NewRouter()
+				for _, p := range patterns {
+					r.Handle("GET", p, nopHandler{})
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/route")
+		})
+	}
+}
+
+func BenchmarkScale_HandleBulk(b *testing.B) {
+	for _, n := range scaleSizes {
+		entries := make([]Entry, n)
+		for i, p := range scaleRoutes(n) {
+			entries[i] = Entry{"GET", p, nopHandler{}}
+		}
+		b.Run(fmt.Sprintf("routes=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewRouter().HandleBulk(entries)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/route")
+		})
+	}
+}
+
+// BenchmarkScale_Lookup measures the tree lookup alone, i.e. without
+// the static fast path which would otherwise serve all of the routes.
+func BenchmarkScale_Lookup(b *testing.B) {
+	for _, n := range scaleSizes {
+		patterns := scaleRoutes(n)
+		r := NewRouter()
+		for _, p := range patterns {
+			r.Handle("GET", p, nopHandler{})
+		}
+		sample := make([]string, 1024)
+		for i := range sample {
+			sample[i] = patterns[(i*7919)%n]
+		}
+		b.Run(fmt.Sprintf("routes=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if lf, _, _, _ := r.tree.lookup(sample[i%len(sample)], nil, nil); lf == 0 {
+					b.Fatal("route not found")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScale_Memory reports the heap memory retained by a Router per route.
+func BenchmarkScale_Memory(b *testing.B) {
+	for _, n := range scaleSizes {
+		patterns := scaleRoutes(n)
+		b.Run(fmt.Sprintf("routes=%d", n), func(b *testing.B) {
+			var before, after runtime.MemStats
+			var total uint64
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				r := NewRouter()
+				for _, p := range patterns {
+					r.Handle("GET", p, nopHandler{})
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(r)
+				total += after.HeapAlloc - before.HeapAlloc
+			}
+			b.ReportMetric(float64(total)/float64(b.N*n), "B/route")
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Command routestat reads a route table and HTTP access logs and reports, for
+// each pattern of the table, the number of requests it matched, the classes of
+// their status codes and the percentiles of their latencies. It also lists the
+// patterns that matched no request, i.e. the dead routes, and the URLs that
+// matched no pattern, e.g. the paths probed by scanners. The requests that the
+// Router redirects to the path with or without a trailing slash are reported
+// separately, by the pattern they are redirected to. The requests are
+// matched by a route.Router loaded with the table, i.e. exactly the way the
+// Router would match them in production.
+//
+// Usage:
+//
+//	routestat -routes file [flags] [log files]
+//
+// The logs are read from the given files, or from the standard input if none
+// are given. The lines that cannot be parsed, or that are longer than 1 MiB,
+// are skipped. The flags are:
+//
+//	-routes file
+//		The route table, either a JSON array of objects with the "method" and
+//		"pattern" of each route, or a listing with one route per line, its
+//		methods separated by commas and its pattern, e.g. "GET,HEAD /users/{id}".
+//		A line with a pattern only matches any method, the fields after the
+//		pattern are ignored, as are empty lines and lines starting with '#'.
+//	-format auto|common|combined|json
+//		The format of the logs, by default detected for each line. The common
+//		and combined formats may have an extra field with the request's latency.
+//		The JSON lines are objects with fields such as "method", "uri" or "url",
+//		"host", "status" and "duration", or "request" with the request line.
+//	-unit duration
+//		The unit of the latencies written as plain numbers, 1s by default,
+//		e.g. 1ms for milliseconds or 1us for the microseconds of Apache's %D.
+//	-host host
+//		The host of the requests whose log lines have no host, which are
+//		otherwise matched only against the patterns without a host.
+//	-top n
+//		The number of unmatched URLs to list, 20 by default, 0 for all.
+//	-json
+//		Write the report as JSON instead of text.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "routestat:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("routestat", flag.ContinueOnError)
+	var (
+		routes = fs.String("routes", "", "the route `file`")
+		format = fs.String("format", "auto", "the log `format`: auto, common, combined or json")
+		unit   = fs.Duration("unit", time.Second, "the `unit` of the latencies written as plain numbers")
+		host   = fs.String("host", "", "the `host` of the requests whose log lines have no host")
+		top    = fs.Int("top", 20, "the number of unmatched URLs to list, 0 for all")
+		asJSON = fs.Bool("json", false, "write the report as JSON")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *routes == "" {
+		return errors.New("missing the -routes flag")
+	}
+	if *unit <= 0 {
+		return fmt.Errorf("invalid unit %s", *unit)
+	}
+	f, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	table, err := loadRoutes(*routes)
+	if err != nil {
+		return err
+	}
+	a, err := newAnalyzer(table, *host)
+	if err != nil {
+		return err
+	}
+
+	p := &parser{format: f, unit: *unit}
+	if files := fs.Args(); len(files) > 0 {
+		for _, name := range files {
+			if err := readLogFile(name, p, a); err != nil {
+				return err
+			}
+		}
+	} else if err := readLog(stdin, p, a); err != nil {
+		return err
+	}
+
+	rep := a.report(*top)
+	if *asJSON {
+		return rep.writeJSON(stdout)
+	}
+	return rep.writeText(stdout)
+}
+
+func readLogFile(name string, p *parser, a *analyzer) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := readLog(f, p, a); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// maxLine is the maximum length of a log line, the longer lines are skipped.
+const maxLine = 1024 * 1024
+
+// readLog adds the requests of the log's lines to the analyzer. The lines
+// that cannot be parsed, or that are longer than maxLine, are counted as
+// skipped.
+func readLog(r io.Reader, p *parser, a *analyzer) error {
+	br := bufio.NewReaderSize(r, maxLine)
+	for {
+		b, isPrefix, err := br.ReadLine()
+		if isPrefix {
+			// discard the rest of the line
+			for isPrefix && err == nil {
+				_, isPrefix, err = br.ReadLine()
+			}
+			a.skipped++
+		} else if len(b) > 0 {
+			if line := string(b); !isBlank(line) {
+				if rq, err := p.parse(line); err != nil {
+					a.skipped++
+				} else {
+					a.add(rq)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// routeSpec is a route of the route table.
+type routeSpec struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+}
+
+// loadRoutes reads the route table from the named file.
+func loadRoutes(name string) ([]routeSpec, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := parseRoutes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return routes, nil
+}
+
+// parseRoutes parses a route table, either a JSON array or a listing.
+func parseRoutes(data []byte) ([]routeSpec, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var routes []routeSpec
+		if err := json.Unmarshal(trimmed, &routes); err != nil {
+			return nil, err
+		}
+		for i := range routes {
+			if routes[i].Pattern == "" {
+				return nil, fmt.Errorf("route #%d: missing pattern", i)
+			}
+			if routes[i].Method == "" {
+				routes[i].Method = "*"
+			}
+		}
+		return routes, nil
+	}
+
+	var routes []routeSpec
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case len(fields) == 1:
+			routes = append(routes, routeSpec{Method: "*", Pattern: fields[0]})
+		case strings.ContainsAny(fields[0], "/{"):
+			return nil, fmt.Errorf("line %d: %q is not a method", i+1, fields[0])
+		default:
+			routes = append(routes, routeSpec{Method: fields[0], Pattern: fields[1]})
+		}
+	}
+	return routes, nil
+}
+
+// request is a request read from an access log.
+type request struct {
+	method string
+	host   string // empty if the log line has none
+	target string // the request target, i.e. a path or an absolute URL
+	status int
+	// The latency of the request, if the log line has one.
+	latency    time.Duration
+	hasLatency bool
+}
+
+type format int
+
+const (
+	formatAuto format = iota
+	formatCommon
+	formatCombined
+	formatJSON
+)
+
+func parseFormat(s string) (format, error) {
+	switch s {
+	case "auto":
+		return formatAuto, nil
+	case "common":
+		return formatCommon, nil
+	case "combined":
+		return formatCombined, nil
+	case "json":
+		return formatJSON, nil
+	}
+	return 0, fmt.Errorf("unknown log format %q", s)
+}
+
+// parser parses the lines of an access log.
+type parser struct {
+	format format
+	unit   time.Duration // the unit of the latencies written as plain numbers
+}
+
+// parse parses a log line.
+func (p *parser) parse(line string) (request, error) {
+	line = strings.TrimSpace(line)
+	f := p.format
+	if f == formatAuto {
+		if f = formatCommon; strings.HasPrefix(line, "{") {
+			f = formatJSON
+		}
+	}
+	if f == formatJSON {
+		return p.parseJSON(line)
+	}
+	return p.parseCLF(line, f)
+}
+
+// parseCLF parses a line in the Common Log Format, i.e.
+//
+//	host ident authuser [date] "request" status bytes
+//
+// or in the Combined Log Format, which adds the quoted referer and user
+// agent. Either may be followed by a field with the request's latency.
+// If the format is formatCommon, the combined fields are still detected.
+func (p *parser) parseCLF(line string, f format) (rq request, err error) {
+	fields, err := splitFields(line)
+	if err != nil {
+		return rq, err
+	}
+	if len(fields) < 7 {
+		return rq, errors.New("too few fields")
+	}
+	if !fields[3].bracketed || !fields[4].quoted {
+		return rq, errors.New("malformed date or request field")
+	}
+
+	method, target, ok := parseRequestLine(fields[4].text)
+	if !ok {
+		return rq, fmt.Errorf("malformed request %q", fields[4].text)
+	}
+	rq.method, rq.target = method, target
+	if rq.status, err = strconv.Atoi(fields[5].text); err != nil {
+		return rq, fmt.Errorf("malformed status %q", fields[5].text)
+	}
+
+	rest := fields[7:]
+	if f == formatCombined || (len(rest) >= 2 && rest[0].quoted && rest[1].quoted) {
+		if len(rest) < 2 {
+			return rq, errors.New("missing the referer or user agent")
+		}
+		rest = rest[2:]
+	}
+	if len(rest) > 0 && !rest[0].quoted {
+		if rq.latency, err = parseLatency(rest[0].text, p.unit); err != nil {
+			return rq, err
+		}
+		rq.hasLatency = true
+	}
+	return rq, nil
+}
+
+// parseRequestLine returns the method and target of a request line,
+// e.g. "GET /users/1 HTTP/1.1".
+func parseRequestLine(s string) (method, target string, ok bool) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// field is a field of a log line.
+type field struct {
+	text      string
+	quoted    bool // the text was in double quotes
+	bracketed bool // the text was in square brackets
+}
+
+// splitFields splits a log line into its fields, which are separated by spaces,
+// except for the fields in double quotes, in which quotes and backslashes may be
+// escaped with a backslash, and the fields in square brackets.
+func splitFields(line string) ([]field, error) {
+	var fields []field
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t':
+			i++
+		case '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' && j+1 < len(line) && (line[j+1] == '"' || line[j+1] == '\\') {
+					j++
+				}
+				b.WriteByte(line[j])
+			}
+			if j == len(line) {
+				return nil, errors.New("unterminated quoted field")
+			}
+			fields = append(fields, field{text: b.String(), quoted: true})
+			i = j + 1
+		case '[':
+			j := strings.IndexByte(line[i:], ']')
+			if j == -1 {
+				return nil, errors.New("unterminated bracketed field")
+			}
+			fields = append(fields, field{text: line[i+1 : i+j], bracketed: true})
+			i += j + 1
+		default:
+			j := strings.IndexAny(line[i:], " \t")
+			if j == -1 {
+				j = len(line) - i
+			}
+			fields = append(fields, field{text: line[i : i+j]})
+			i += j
+		}
+	}
+	return fields, nil
+}
+
+// The keys of the JSON log fields, in the order of preference.
+var (
+	jsonMethodKeys  = []string{"method", "request_method", "http_method"}
+	jsonTargetKeys  = []string{"uri", "request_uri", "url", "path"}
+	jsonHostKeys    = []string{"host", "http_host", "server_name"}
+	jsonRequestKeys = []string{"request"}
+	jsonStatusKeys  = []string{"status", "status_code", "code"}
+	jsonLatencyKeys = []string{"duration", "latency", "request_time", "response_time", "elapsed"}
+)
+
+// parseJSON parses a log line that holds a JSON object.
+func (p *parser) parseJSON(line string) (rq request, err error) {
+	var obj map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return rq, err
+	}
+
+	rq.method, _ = jsonString(obj, jsonMethodKeys)
+	rq.target, _ = jsonString(obj, jsonTargetKeys)
+	rq.host, _ = jsonString(obj, jsonHostKeys)
+	if s, ok := jsonString(obj, jsonRequestKeys); ok && (rq.method == "" || rq.target == "") {
+		if rq.method, rq.target, ok = parseRequestLine(s); !ok {
+			return rq, fmt.Errorf("malformed request %q", s)
+		}
+	}
+	if rq.method == "" || rq.target == "" {
+		return rq, errors.New("missing the method or URL")
+	}
+
+	if s, ok := jsonString(obj, jsonStatusKeys); ok {
+		if rq.status, err = strconv.Atoi(s); err != nil {
+			return rq, fmt.Errorf("malformed status %q", s)
+		}
+	}
+	if s, ok := jsonString(obj, jsonLatencyKeys); ok {
+		if rq.latency, err = parseLatency(s, p.unit); err != nil {
+			return rq, err
+		}
+		rq.hasLatency = true
+	}
+	return rq, nil
+}
+
+// jsonString returns the value of the first of the keys that the object
+// has, as a string, and reports whether the value is a string or a number.
+func jsonString(obj map[string]interface{}, keys []string) (string, bool) {
+	for _, k := range keys {
+		switch v := obj[k].(type) {
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		}
+	}
+	return "", false
+}
+
+// parseLatency parses a latency written either as a Go duration, e.g. "12ms",
+// or as a plain number of the given unit, e.g. "0.012" seconds.
+func parseLatency(s string, unit time.Duration) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("malformed latency %q", s)
+	}
+	return time.Duration(f * float64(unit)), nil
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func equals(t *testing.T, i int, got, want interface{}) {
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("#%d: got %v, want %v", i, got, want)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		data   string
+		routes []routeSpec
+		err    string
+	}{{
+		data: "# the API\nGET /users\n\nGET,POST /users/{id}  handlers.User\n/static/*file\n",
+		routes: []routeSpec{
+			{"GET", "/users"},
+			{"GET,POST", "/users/{id}"},
+			{"*", "/static/*file"},
+		},
+	}, {
+		data: `[{"method": "GET", "pattern": "/users"}, {"Method": "POST", "Pattern": "/users"}, {"pattern": "/x"}]`,
+		routes: []routeSpec{
+			{"GET", "/users"},
+			{"POST", "/users"},
+			{"*", "/x"},
+		},
+	}, {
+		data: "/users GET\n",
+		err:  `line 1: "/users" is not a method`,
+	}, {
+		data: `[{"method": "GET"}]`,
+		err:  "route #0: missing pattern",
+	}}
+
+	for i, tt := range tests {
+		routes, err := parseRoutes([]byte(tt.data))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("#%d: got error %v, want %s", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		equals(t, i, routes, tt.routes)
+	}
+}
+
+func TestParserParse(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		format format
+		unit   time.Duration
+		line   string
+		rq     request
+		err    string
+	}{{
+		line: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /users/1?tab=x HTTP/1.0" 200 2326`,
+		rq:   request{method: "GET", target: "/users/1?tab=x", status: 200},
+	}, {
+		line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "POST /users HTTP/1.1" 201 - "http://example.com/" "Mozilla/5.0 (X11; \"Linux\")"`,
+		rq:   request{method: "POST", target: "/users", status: 201},
+	}, {
+		line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 404 10 "-" "curl/8.0" 0.125`,
+		rq:   request{method: "GET", target: "/", status: 404, latency: 125 * time.Millisecond, hasLatency: true},
+	}, {
+		unit: time.Microsecond,
+		line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 200 10 1500`,
+		rq:   request{method: "GET", target: "/", status: 200, latency: 1500 * time.Microsecond, hasLatency: true},
+	}, {
+		format: formatCombined,
+		line:   `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 200 10`,
+		err:    "missing the referer or user agent",
+	}, {
+		line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "\x16\x03\x01" 400 0`,
+		err:  `malformed request "\\x16\\x03\\x01"`,
+	}, {
+		line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /`,
+		err:  "unterminated quoted field",
+	}, {
+		line: `{"method": "GET", "uri": "/users/1", "host": "api.example.com", "status": 200, "duration": "3ms"}`,
+		rq:   request{method: "GET", host: "api.example.com", target: "/users/1", status: 200, latency: 3 * time.Millisecond, hasLatency: true},
+	}, {
+		line: `{"request": "DELETE /users/1 HTTP/1.1", "status": "204", "request_time": 0.5}`,
+		rq:   request{method: "DELETE", target: "/users/1", status: 204, latency: 500 * time.Millisecond, hasLatency: true},
+	}, {
+		line: `{"status": 200}`,
+		err:  "missing the method or URL",
+	}, {
+		format: formatJSON,
+		line:   `not json`,
+		err:    "invalid character 'o' in literal null (expecting 'u')",
+	}}
+
+	for i, tt := range tests {
+		if tt.unit == 0 {
+			tt.unit = time.Second
+		}
+		p := &parser{format: tt.format, unit: tt.unit}
+		rq, err := p.parse(tt.line)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("#%d: got error %v, want %s", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		equals(t, i, rq, tt.rq)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frk/route"
+)
+
+// analyzer matches the requests against the routes of the route table
+// and aggregates them by the pattern they match.
+type analyzer struct {
+	router    *route.Router
+	host      string   // the host of the requests without one
+	patterns  []string // the distinct patterns of the table, in order
+	stats     map[string]*patternStats
+	redirects map[string]*patternStats // by the pattern redirected to
+	unmatched map[string]int
+	skipped   int
+}
+
+type patternStats struct {
+	requests  int
+	status    map[string]int
+	latencies []time.Duration
+}
+
+// newAnalyzer returns an analyzer for the routes, which must be valid Router routes.
+func newAnalyzer(routes []routeSpec, host string) (*analyzer, error) {
+	a := &analyzer{
+		router:    route.NewRouter(),
+		host:      host,
+		stats:     make(map[string]*patternStats),
+		redirects: make(map[string]*patternStats),
+		unmatched: make(map[string]int),
+	}
+	seen := make(map[routeSpec]bool)
+	for _, rs := range routes {
+		if seen[rs] {
+			continue
+		}
+		seen[rs] = true
+		if err := handle(a.router, rs); err != nil {
+			return nil, err
+		}
+		if a.stats[rs.Pattern] == nil {
+			a.stats[rs.Pattern] = &patternStats{status: make(map[string]int)}
+			a.patterns = append(a.patterns, rs.Pattern)
+		}
+	}
+	return a, nil
+}
+
+// handle registers the route with the router, turning the
+// panic of an invalid or conflicting route into an error.
+func handle(r *route.Router, rs routeSpec) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+	r.Handle(rs.Method, rs.Pattern, route.HandlerFunc(route.NotFound))
+	return nil
+}
+
+// add matches the request and adds it to the stats of the pattern it matches,
+// to those of the redirects to the pattern if the Router redirects the request
+// to the path with or without a trailing slash, or to the unmatched URLs. The
+// requests with a method that the pattern's routes do not handle are counted
+// for the pattern.
+func (a *analyzer) add(rq request) {
+	u, err := url.ParseRequestURI(rq.target)
+	if err != nil {
+		a.unmatched[rq.target]++
+		return
+	}
+	host := rq.host
+	if u.Host != "" {
+		host = u.Host
+	} else if host == "" {
+		host = a.host
+	}
+
+	req := &http.Request{Method: rq.method, Host: host, URL: u, Header: http.Header{}}
+	_, _, pat := a.router.Handler(req)
+	s := a.stats[pat]
+	if s == nil {
+		s = a.redirect(rq.method, host, u)
+	}
+	if s == nil {
+		key := u.Path
+		if u.Host != "" {
+			key = u.Host + u.Path
+		}
+		a.unmatched[key]++
+		return
+	}
+
+	s.requests++
+	s.status[statusClass(rq.status)]++
+	if rq.hasLatency {
+		s.latencies = append(s.latencies, rq.latency)
+	}
+}
+
+// redirect returns the stats of the redirects to the pattern that matches the
+// location to which the Router redirects a request with the method, host and
+// URL, nil if the Router does not redirect the request.
+func (a *analyzer) redirect(method, host string, u *url.URL) *patternStats {
+	e := a.router.Explain(method, host, u.EscapedPath())
+	if e.Status != http.StatusMovedPermanently {
+		return nil
+	}
+	req := &http.Request{Method: method, Host: host, URL: &url.URL{Path: e.Location}, Header: http.Header{}}
+	_, _, pat := a.router.Handler(req)
+	if pat == "" {
+		return nil
+	}
+	s := a.redirects[pat]
+	if s == nil {
+		s = &patternStats{status: make(map[string]int)}
+		a.redirects[pat] = s
+	}
+	return s
+}
+
+// statusClass returns the class of the status code, e.g. "2xx".
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "other"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+type report struct {
+	Routes []routeReport `json:"routes"`
+	// The trailing slash redirects, by the pattern they redirect to.
+	Redirects []routeReport `json:"redirects"`
+	Dead      []string      `json:"dead"`
+	Unmatched []urlCount    `json:"unmatched"`
+	// The total number of unmatched requests, including those of the URLs
+	// that are not listed.
+	UnmatchedRequests int `json:"unmatched_requests"`
+	Skipped           int `json:"skipped_lines"`
+}
+
+type routeReport struct {
+	Pattern  string         `json:"pattern"`
+	Requests int            `json:"requests"`
+	Status   map[string]int `json:"status"`
+	// The latency percentiles, in milliseconds, of the requests
+	// whose log lines have a latency, omitted if none have one.
+	P50 *float64 `json:"p50_ms,omitempty"`
+	P90 *float64 `json:"p90_ms,omitempty"`
+	P99 *float64 `json:"p99_ms,omitempty"`
+}
+
+type urlCount struct {
+	URL      string `json:"url"`
+	Requests int    `json:"requests"`
+}
+
+// report returns the report of the requests added so far. The matched patterns
+// are sorted by their number of requests, the dead patterns are in the order of
+// the route table, and at most top unmatched URLs are listed, all if top is 0.
+func (a *analyzer) report(top int) *report {
+	rep := &report{Skipped: a.skipped, Dead: []string{}, Routes: []routeReport{}, Redirects: []routeReport{}, Unmatched: []urlCount{}}
+	for _, pat := range a.patterns {
+		if s := a.redirects[pat]; s != nil {
+			rep.Redirects = append(rep.Redirects, s.report(pat))
+		}
+		s := a.stats[pat]
+		if s.requests == 0 {
+			rep.Dead = append(rep.Dead, pat)
+			continue
+		}
+		rep.Routes = append(rep.Routes, s.report(pat))
+	}
+	byRequests := func(rs []routeReport) {
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].Requests > rs[j].Requests })
+	}
+	byRequests(rep.Routes)
+	byRequests(rep.Redirects)
+
+	for u, n := range a.unmatched {
+		rep.Unmatched = append(rep.Unmatched, urlCount{URL: u, Requests: n})
+		rep.UnmatchedRequests += n
+	}
+	sort.Slice(rep.Unmatched, func(i, j int) bool {
+		ui, uj := rep.Unmatched[i], rep.Unmatched[j]
+		return ui.Requests > uj.Requests || (ui.Requests == uj.Requests && ui.URL < uj.URL)
+	})
+	if top > 0 && len(rep.Unmatched) > top {
+		rep.Unmatched = rep.Unmatched[:top]
+	}
+	return rep
+}
+
+// report returns the report of the pattern's stats.
+func (s *patternStats) report(pat string) routeReport {
+	rr := routeReport{Pattern: pat, Requests: s.requests, Status: s.status}
+	if len(s.latencies) > 0 {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		rr.P50 = percentile(s.latencies, 50)
+		rr.P90 = percentile(s.latencies, 90)
+		rr.P99 = percentile(s.latencies, 99)
+	}
+	return rr
+}
+
+// percentile returns the nearest-rank percentile p of the sorted
+// latencies, in milliseconds.
+func percentile(sorted []time.Duration, p float64) *float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	ms := float64(sorted[i]) / float64(time.Millisecond)
+	return &ms
+}
+
+func (rep *report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func (rep *report) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REQUESTS\t2XX\t3XX\t4XX\t5XX\tP50\tP90\tP99\tPATTERN")
+	row := func(rr routeReport, pattern string) {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", rr.Requests,
+			rr.Status["2xx"], rr.Status["3xx"], rr.Status["4xx"], rr.Status["5xx"],
+			millis(rr.P50), millis(rr.P90), millis(rr.P99), pattern)
+	}
+	for _, rr := range rep.Routes {
+		row(rr, rr.Pattern)
+	}
+	for _, rr := range rep.Redirects {
+		row(rr, "redirect → "+rr.Pattern)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nDead routes (%d):\n", len(rep.Dead))
+	for _, pat := range rep.Dead {
+		fmt.Fprintf(w, "  %s\n", pat)
+	}
+
+	fmt.Fprintf(w, "\nUnmatched requests (%d):\n", rep.UnmatchedRequests)
+	for _, uc := range rep.Unmatched {
+		fmt.Fprintf(tw, "  %d\t%s\n", uc.Requests, uc.URL)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if rep.Skipped > 0 {
+		fmt.Fprintf(w, "\nSkipped %d malformed log lines.\n", rep.Skipped)
+	}
+	return nil
+}
+
+func millis(ms *float64) string {
+	if ms == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*ms, 'f', -1, 64) + "ms"
+}
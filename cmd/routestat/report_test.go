@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRoutes = `
+GET /users
+GET,POST /users/{id}
+GET /users/{id}/posts/{pid}
+GET /static/*file
+GET /admin
+GET api.example.com/v1/{res}
+`
+
+const testLog = `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /users/1 HTTP/1.1" 200 10 0.010
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /users/2?tab=x HTTP/1.1" 200 10 0.020
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "DELETE /users/2 HTTP/1.1" 405 10 0.001
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /users/1/posts/9 HTTP/1.1" 500 10 "-" "curl/8.0" 1.5
+{"method": "GET", "uri": "/static/css/app.css", "status": 304}
+{"method": "GET", "uri": "/v1/orders", "host": "api.example.com", "status": 200, "duration": "5ms"}
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /wp-login.php HTTP/1.1" 404 10
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "POST /wp-login.php HTTP/1.1" 404 10
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /users/ HTTP/1.1" 301 10
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /v1/orders HTTP/1.1" 404 10
+garbage
+
+`
+
+func writeTestFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	//t.Skip()
+	routes := writeTestFile(t, "routes.txt", testRoutes)
+
+	var out bytes.Buffer
+	if err := run([]string{"-routes", routes, "-json"}, strings.NewReader(testLog), &out); err != nil {
+		t.Fatal(err)
+	}
+	var got report
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	ms := func(f float64) *float64 { return &f }
+	want := report{
+		Routes: []routeReport{
+			{Pattern: "/users/{id}", Requests: 3, Status: map[string]int{"2xx": 2, "4xx": 1}, P50: ms(10), P90: ms(20), P99: ms(20)},
+			{Pattern: "/users/{id}/posts/{pid}", Requests: 1, Status: map[string]int{"5xx": 1}, P50: ms(1500), P90: ms(1500), P99: ms(1500)},
+			{Pattern: "/static/*file", Requests: 1, Status: map[string]int{"3xx": 1}},
+			{Pattern: "api.example.com/v1/{res}", Requests: 1, Status: map[string]int{"2xx": 1}, P50: ms(5), P90: ms(5), P99: ms(5)},
+		},
+		Redirects: []routeReport{
+			{Pattern: "/users", Requests: 1, Status: map[string]int{"3xx": 1}},
+		},
+		Dead: []string{"/users", "/admin"},
+		Unmatched: []urlCount{
+			{URL: "/wp-login.php", Requests: 2},
+			{URL: "/v1/orders", Requests: 1},
+		},
+		UnmatchedRequests: 3,
+		Skipped:           1,
+	}
+	equals(t, 0, got, want)
+
+	// the requests without a host are given the -host flag's host
+	out.Reset()
+	if err := run([]string{"-routes", routes, "-host", "api.example.com", "-top", "1"}, strings.NewReader(testLog), &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, s := range []string{
+		"REQUESTS  2XX  3XX  4XX  5XX  P50     P90     P99     PATTERN\n",
+		"3         2    0    1    0    10ms    20ms    20ms    /users/{id}\n",
+		"2         1    0    1    0    5ms     5ms     5ms     api.example.com/v1/{res}\n",
+		"1         0    1    0    0    -       -       -       /static/*file\n",
+		"1         0    1    0    0    -       -       -       redirect → /users\n",
+		"Dead routes (2):\n  /users\n  /admin\n",
+		"Unmatched requests (2):\n  2  /wp-login.php\n\n",
+		"Skipped 1 malformed log lines.\n",
+	} {
+		if !strings.Contains(text, s) {
+			t.Errorf("the text report does not contain %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	//t.Skip()
+	conflict := writeTestFile(t, "conflict.txt", "GET /users/{id}\nGET /users/{name}\n")
+	duplicate := writeTestFile(t, "duplicate.txt", "GET /users/{id}\nGET /users/{id}\nPOST /users/{id}\n")
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{}, "missing the -routes flag"},
+		{[]string{"-routes", duplicate, "-format", "xml"}, `unknown log format "xml"`},
+		{[]string{"-routes", duplicate, "-unit", "0s"}, "invalid unit 0s"},
+		{[]string{"-routes", conflict}, `route.Handle: GET /users/{name}: The param name "name" conflicts with the param name "id" in the same segment of a previously registered pattern.`},
+		{[]string{"-routes", duplicate}, ""},
+	}
+	for i, tt := range tests {
+		var out bytes.Buffer
+		err := run(tt.args, strings.NewReader(""), &out)
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("#%d: got error %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+func TestRun_LongLines(t *testing.T) {
+	//t.Skip()
+	routes := writeTestFile(t, "routes.txt", testRoutes)
+	long := `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /users/` + strings.Repeat("x", maxLine) + ` HTTP/1.1" 200 10`
+	log := long + "\n" + testLog + long
+
+	var out bytes.Buffer
+	if err := run([]string{"-routes", routes, "-json"}, strings.NewReader(log), &out); err != nil {
+		t.Fatal(err)
+	}
+	var got report
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	// the long lines are skipped along with the malformed one
+	equals(t, 0, got.Skipped, 3)
+	equals(t, 1, got.Routes[0].Requests, 3)
+	equals(t, 2, got.UnmatchedRequests, 3)
+}
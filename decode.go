@@ -0,0 +1,255 @@
+package route
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeError is returned by Decode and holds the errors of all the
+// parameters that could not be decoded.
+type DecodeError struct {
+	Errors []*ParamError
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the individual parameters.
+func (e *DecodeError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decode stores the parameter values in the fields of the struct pointed to by dst.
+// The parameter key of a field is specified with the "route" tag, e.g.
+//
+//	type PostParams struct {
+//		ID      int       `route:"post_id"`
+//		Slug    string    `route:"slug,required"`
+//		Created time.Time `route:"date,layout=2006-01-02"`
+//	}
+//
+// Fields without the tag, or with the tag "-", are ignored, the fields of embedded
+// structs without the tag are decoded as if they were fields of the outer struct.
+// A field whose parameter is missing is left unchanged, unless its tag has the
+// "required" option in which case the error is a *ParamError wrapping ErrNoParam.
+//
+// Decode supports the field types parsed by the typed methods of Params, i.e. bool,
+// string, the int, uint and float types and time.Time, whose layout defaults to
+// time.RFC3339 unless specified with the "layout" option, as well as any type that
+// implements encoding.TextUnmarshaler and pointers to any of those types. Slices of
+// those types are decoded from the segments of the value, as returned by Segments,
+// which is useful with catch-all and repeated parameters, except for []byte which
+// holds the value's bytes. Parsers registered with RegisterParser take precedence
+// over the built-in parsing.
+//
+// If any of the parameters cannot be decoded Decode returns a *DecodeError that
+// holds a *ParamError for each of them.
+func (ps Params) Decode(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("route: Decode requires a non-nil pointer to a struct, got %T", dst)
+	}
+
+	var de DecodeError
+	decodeStruct(rv.Elem(), &de, ps, "route")
+	if len(de.Errors) > 0 {
+		return &de
+	}
+	return nil
+}
+
+// lookup implements the valueSource interface.
+func (ps Params) lookup(_, key string) ([]string, bool) {
+	if v, ok := ps.get(key); ok {
+		return []string{v}, true
+	}
+	return nil, false
+}
+
+// The valueSource interface is implemented by the types whose values
+// can be decoded into struct fields by decodeStruct.
+type valueSource interface {
+	// lookup returns the values associated with the key in the part
+	// of the source that is identified by the given tag name.
+	lookup(tag, key string) ([]string, bool)
+}
+
+// decodeStruct decodes the values of src into the fields of sv that have any
+// of the given tags, if a field has more than one of the tags the first one wins.
+func decodeStruct(sv reflect.Value, de *DecodeError, src valueSource, tags ...string) {
+	st := sv.Type()
+Fields:
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+
+		var name, tag string
+		for _, name = range tags {
+			var ok bool
+			if tag, ok = sf.Tag.Lookup(name); ok {
+				break
+			}
+			name = ""
+		}
+		if name == "" {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				decodeStruct(sv.Field(i), de, src, tags...)
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		key, opts := parseTag(tag)
+		vals, ok := src.lookup(name, key)
+		if !ok || len(vals) == 0 {
+			if opts.required {
+				de.Errors = append(de.Errors, noParam(key, sf.Type.String()))
+			}
+			continue
+		}
+
+		fv := sv.Field(i)
+		if (len(vals) > 1 || name == "query") && isListType(fv.Type()) {
+			lv := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+			for j, val := range vals {
+				if err := decodeValue(lv.Index(j), val, opts); err != nil {
+					de.Errors = append(de.Errors, &ParamError{key, val, sf.Type.String(), err})
+					continue Fields
+				}
+			}
+			fv.Set(lv)
+			continue
+		}
+		if err := decodeValue(fv, vals[0], opts); err != nil {
+			de.Errors = append(de.Errors, &ParamError{key, vals[0], sf.Type.String(), err})
+		}
+	}
+}
+
+// isListType reports whether values of the type t are decoded from
+// a list of values, one per element, rather than from a single value.
+func isListType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 &&
+		lookupParser(t) == nil && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+type tagOpts struct {
+	required bool
+	layout   string
+}
+
+func parseTag(tag string) (key string, opts tagOpts) {
+	opts.layout = time.RFC3339
+
+	parts := strings.Split(tag, ",")
+	for _, p := range parts[1:] {
+		if p == "required" {
+			opts.required = true
+		} else if strings.HasPrefix(p, "layout=") {
+			opts.layout = p[len("layout="):]
+		}
+	}
+	return parts[0], opts
+}
+
+func decodeValue(fv reflect.Value, val string, opts tagOpts) error {
+	if parse := lookupParser(fv.Type()); parse != nil {
+		v, err := parse(val)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			// a nil pointer, map, slice or interface
+			fv.Set(reflect.Zero(fv.Type()))
+			return nil
+		}
+		fv.Set(reflect.ValueOf(v))
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		pv := reflect.New(fv.Type().Elem())
+		if err := decodeValue(pv.Elem(), val, opts); err != nil {
+			return err
+		}
+		fv.Set(pv)
+		return nil
+	}
+
+	if fv.Type() == timeType {
+		t, err := time.Parse(opts.layout, val)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			fv.SetBytes([]byte(val))
+			break
+		}
+		segs := splitSegments(val)
+		sv := reflect.MakeSlice(fv.Type(), len(segs), len(segs))
+		for i, seg := range segs {
+			if err := decodeValue(sv.Index(i), seg, opts); err != nil {
+				return err
+			}
+		}
+		fv.Set(sv)
+	default:
+		return errors.New("unsupported type")
+	}
+	return nil
+}
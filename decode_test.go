@@ -0,0 +1,90 @@
+package route
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type decodeEmbedded struct {
+	Page uint16 `route:"page"`
+}
+
+type decodeTarget struct {
+	decodeEmbedded
+	ID      int       `route:"id"`
+	Slug    string    `route:"slug,required"`
+	Draft   bool      `route:"draft"`
+	Score   float64   `route:"score"`
+	Big     uint64    `route:"big"`
+	Date    time.Time `route:"date,layout=2006-01-02"`
+	Created time.Time `route:"created"`
+	IP      net.IP    `route:"ip"`
+	Ptr     *int8     `route:"ptr"`
+	Raw     []byte    `route:"raw"`
+	Ignored string    `route:"-"`
+	NoTag   string
+}
+
+func TestParamsDecode(t *testing.T) {
+	ptr := int8(-12)
+	tests := []struct {
+		params Params
+		want   decodeTarget
+		errs   []*ParamError
+	}{{
+		params: NewParams(
+			"id", "42", "slug", "hello", "draft", "true", "score", "1.5",
+			"big", "18446744073709551615", "date", "2024-02-29",
+			"created", "2024-02-29T10:00:00Z", "ip", "10.0.0.1", "ptr", "-12",
+			"raw", "a/b//c", "page", "3", "Ignored", "x", "NoTag", "y",
+		),
+		want: decodeTarget{
+			decodeEmbedded: decodeEmbedded{Page: 3},
+			ID:             42,
+			Slug:           "hello",
+			Draft:          true,
+			Score:          1.5,
+			Big:            18446744073709551615,
+			Date:           time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			Created:        time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+			IP:             net.ParseIP("10.0.0.1"),
+			Ptr:            &ptr,
+			Raw:            []byte("a/b//c"),
+		},
+	}, {
+		params: NewParams("id", "abc", "page", "70000"),
+		errs: []*ParamError{
+			{"page", "70000", "uint16", &strconv.NumError{Func: "ParseUint", Num: "70000", Err: strconv.ErrRange}},
+			{"id", "abc", "int", &strconv.NumError{Func: "ParseInt", Num: "abc", Err: strconv.ErrSyntax}},
+			{"slug", "", "string", ErrNoParam("slug")},
+		},
+	}}
+
+	for i, tt := range tests {
+		var got decodeTarget
+		err := tt.params.Decode(&got)
+		if tt.errs != nil {
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("#%d: got err %v, want *DecodeError", i, err)
+			}
+			equals(t, i, de.Errors, tt.errs)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: got %+v, want %+v", i, got, tt.want)
+		}
+	}
+
+	var s decodeTarget
+	if err := (Params{}).Decode(s); err == nil {
+		t.Errorf("Decode(non-pointer) should return an error")
+	}
+}
@@ -0,0 +1,103 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Explanation describes how a Router resolves a request, see Router.Explain.
+type Explanation struct {
+	// The steps taken to resolve the request, in order.
+	Steps []string
+	// The Route whose Handler handles the request, nil if there is none.
+	Route *Route
+	// The pattern that matched the request's path, empty if none did.
+	Pattern string
+	// The params captured from the request's host and path.
+	Params Params
+	// The status implied by the decision, http.StatusOK if the Route's Handler
+	// handles the request. Note that the NotFound, MethodNotAllowed and
+	// InvalidParam handlers installed with the Router may reply differently.
+	Status int
+	// The location of a trailing slash redirect, empty unless the
+	// Status is http.StatusMovedPermanently.
+	Location string
+}
+
+// String returns the numbered steps of the explanation, one per line.
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	for i, s := range e.Steps {
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteString(". ")
+		sb.WriteString(s)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Explain returns a step by step explanation of how the Router resolves a request
+// with the given method, host and path. The explanation traces the lookup in the
+// routing trees, i.e. the edges that were compared, the dynamic nodes remembered
+// as the fallback of the static lookup, the captured param values and the reasons
+// for trailing slash redirects, and ends with the Router's decision. The path is
+// given in its escaped form, as in the URL of a request, and is decoded unless the
+// Router uses raw paths, the same way the path of a request's URL would be.
+//
+// Explain is meant for debugging, it is much slower than the Router's lookup.
+func (r *Router) Explain(method, host, path string) *Explanation {
+	return r.explain(method, host, path, &trace{})
+}
+
+// The explain method resolves a request with the route method, recording the
+// steps and the decision in tr, and returns the explanation of the decision.
+func (r *Router) explain(method, host, path string, tr *trace) *Explanation {
+	u := &url.URL{Path: path}
+	if p, err := url.PathUnescape(path); err == nil && p != path {
+		u.Path, u.RawPath = p, path
+	}
+	req := &http.Request{Method: method, Host: host, URL: u}
+
+	r.mu.RLock()
+	_, ps, pat, rt := r.route(req, host, "", Params{}, tr)
+	r.mu.RUnlock()
+
+	return &Explanation{
+		Steps:    tr.steps,
+		Route:    rt,
+		Pattern:  pat,
+		Params:   ps,
+		Status:   tr.status,
+		Location: tr.location,
+	}
+}
+
+// trace records the steps of a lookup and the decision that follows it.
+type trace struct {
+	steps []string
+
+	// If set, the steps are not recorded, which is used by Router.Validate
+	// that only needs the decisions.
+	discard bool
+
+	// The status and location of the decision, see Explanation.
+	status   int
+	location string
+}
+
+func (tr *trace) add(format string, args ...interface{}) {
+	if tr.discard {
+		return
+	}
+	tr.steps = append(tr.steps, fmt.Sprintf(format, args...))
+}
+
+// decide records the decision with the given status and redirect location
+// as the last step.
+func (tr *trace) decide(status int, location, format string, args ...interface{}) {
+	tr.status, tr.location = status, location
+	tr.add(format, args...)
+}
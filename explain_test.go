@@ -0,0 +1,109 @@
+package route
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouterExplain(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/a/new", "h"},
+		{"GET", "/a/{b}", "h"},
+		{"GET", "/a/{b}/c", "h"},
+		{"GET", "/users/", "h"},
+		{"GET", "/static/*file", "h"},
+	}.Router()
+	router.SetNotFoundFor("/api/", strHandler("nf"))
+	router.Host("{t}.example.com").Handle("POST", "/x/{y}", strHandler("h"))
+
+	tests := []struct {
+		method, host, path string
+		status             int
+		location           string
+		pattern            string
+		params             Params
+		last               string
+	}{{
+		method: "GET", host: "example.com", path: "/a/news",
+		status: 200, pattern: "/a/{b}", params: NewParams("b", "news"),
+		last: `the route registered for GET "/a/{b}" handles the request`,
+	}, {
+		method: "GET", host: "example.com", path: "/a/new",
+		status: 200, pattern: "/a/new", params: Params{},
+		last: `the route registered for GET "/a/new" handles the request`,
+	}, {
+		method: "GET", host: "example.com", path: "/users",
+		status: 301, location: "/users/",
+		last: `redirect to "/users/"`,
+	}, {
+		method: "GET", host: "example.com", path: "/a/x/c/",
+		status: 301, location: "/a/x/c",
+		last: `redirect to "/a/x/c"`,
+	}, {
+		method: "GET", host: "example.com", path: "/static/a/b",
+		status: 200, pattern: "/static/*file", params: NewParams("file", "a/b"),
+		last: `the route registered for GET "/static/*file" handles the request`,
+	}, {
+		method: "GET", host: "foo.example.com:8080", path: "/x/1",
+		status: 405, pattern: "/x/{y}", params: NewParams("t", "foo", "y", "1"),
+		last: `the pattern "/x/{y}" has no route for the method GET, the allowed methods are POST`,
+	}, {
+		method: "GET", host: "example.com", path: "/api/v1",
+		status: 404,
+		last:   "no pattern matches, the not-found handler of the longest matching prefix handles the request",
+	}, {
+		method: "GET", host: "example.com", path: "/nope",
+		status: 404,
+		last:   "no pattern matches, the Router's NotFound handler handles the request",
+	}}
+
+	for i, tt := range tests {
+		e := router.Explain(tt.method, tt.host, tt.path)
+		equals(t, i, e.Status, tt.status)
+		equals(t, i, e.Location, tt.location)
+		equals(t, i, e.Pattern, tt.pattern)
+		equals(t, i, e.Params, tt.params)
+		equals(t, i, e.Steps[len(e.Steps)-1], tt.last)
+		equals(t, i, e.Route != nil, tt.status == http.StatusOK)
+		equals(t, i, strings.Count(e.String(), "\n"), len(e.Steps))
+	}
+
+	e := router.Explain("GET", "example.com", "/a/news")
+	for _, step := range []string{
+		`node "/" has a dynamic child, remember it as the fallback for the path "news"`,
+		`the edge "new" of a child of node "/" is a prefix of the path "news", descend`,
+		`no static match for the path "s" below node "new"`,
+		`fall back to the param {b} of node "/" at the path "news", capture b="news"`,
+	} {
+		if !strings.Contains(e.String(), step) {
+			t.Errorf("explanation is missing the step %q:\n%s", step, e)
+		}
+	}
+}
+
+// TestRouterExplain_Handler checks that the explanations agree with the Router's lookup.
+func TestRouterExplain_Handler(t *testing.T) {
+	routes := append(append([]benchRoute{}, githubRoutes...), staticRoutes...)
+	routes = append(routes, parseRoutes...)
+	router := benchRouter(routes)
+
+	for _, rt := range routes {
+		p := benchPath(rt.path)
+		for _, path := range []string{p, p + "/", strings.TrimSuffix(p, "/"), p + "x", p + "/x"} {
+			for _, method := range []string{rt.method, "PURGE"} {
+				r := mustNewRequest(method, "http://example.com"+path, nil)
+				h, ps, pat := router.Handler(r)
+				e := router.Explain(method, "example.com", path)
+				if e.Pattern != pat || !reflect.DeepEqual(e.Params, ps) {
+					t.Errorf("%s %s: got (%q, %v), want (%q, %v)", method, path, e.Pattern, e.Params, pat, ps)
+				}
+				if _, ok := h.(*methodNotAllowed); ok != (e.Status == http.StatusMethodNotAllowed) {
+					t.Errorf("%s %s: got status %d, handler %T", method, path, e.Status, h)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package route
+
+import (
+	"strings"
+)
+
+// Freeze disallows any further registration of routes with the Router, and with its
+// sub-routers, and compacts the Router's tree into a read-only layout. The nodes of
+// the layout are stored in the order of a breadth-first walk, with the children of
+// each node next to each other, and the edges of all the nodes are substrings of a
+// single string, which uses less memory and improves the locality of the lookups.
+// After Freeze, calling Handle, HandleFunc, SetNotFoundFor or Host with a new host
+// pattern panics. Freeze is meant to be called once all the routes have been
+// registered, typically at startup, calling Freeze more than once has no effect.
+func (r *Router) Freeze() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.frozen {
+		return
+	}
+
+	leaves := r.tree.compact()
+	for pattern, i := range r.static {
+		r.static[pattern] = leaves[i]
+	}
+	r.frozen = true
+	for _, sub := range r.subs {
+		sub.Freeze()
+	}
+}
+
+// Frozen reports whether the Router has been frozen with Freeze.
+func (r *Router) Frozen() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.frozen
+}
+
+// compact rebuilds the tree without the elements that are no longer linked to
+// it. The nodes are stored in the order of a breadth-first walk, in which the
+// children of each node are contiguous, and share a single slice of child
+// indices. The edges and indices of all the nodes are interned, i.e. they
+// are substrings of a single string. The compact method returns the new
+// index of each of the old leaves, 0 for those that were dropped.
+func (t *tree[L]) compact() []int32 {
+	if len(t.nodes) == 0 {
+		return nil
+	}
+
+	// collect the edges and indices, so that they can be sliced from a
+	// single string, and count the elements, so that the slices can be
+	// allocated upfront
+	var text strings.Builder
+	var nodes, children, params, catchalls, leaves int
+	offsets := make(map[string]int)
+	collect := func(s string) {
+		if _, ok := offsets[s]; !ok {
+			offsets[s] = text.Len()
+			text.WriteString(s)
+		}
+	}
+	var walk func(ni int32)
+	walk = func(ni int32) {
+		nd := &t.nodes[ni]
+		nodes++
+		children += len(nd.children)
+		collect(nd.edge)
+		collect(nd.indices)
+		if nd.leaf != 0 {
+			leaves++
+		}
+		if nd.prefix != 0 {
+			leaves++
+		}
+		for _, ci := range nd.children {
+			walk(ci)
+		}
+		if nd.param != 0 {
+			p := &t.params[nd.param]
+			params++
+			if p.leaf != 0 {
+				leaves++
+			}
+			if p.child != 0 {
+				walk(p.child)
+			}
+		}
+		if nd.catchall != 0 {
+			catchalls++
+			if t.catchalls[nd.catchall].leaf != 0 {
+				leaves++
+			}
+		}
+	}
+	walk(0)
+
+	c := tree[L]{
+		sep:             t.sep,
+		renameCatchAlls: t.renameCatchAlls,
+		nodes:           make([]node, 0, nodes),
+		params:          make([]paramNode, 1, params+1),
+		catchalls:       make([]catchallNode, 1, catchalls+1),
+		leaves:          make([]leaf[L], 1, leaves+1),
+		maxParams:       t.maxParams,
+	}
+	str := text.String()
+	intern := func(s string) string {
+		off := offsets[s]
+		return str[off : off+len(s)]
+	}
+	remap := make([]int32, len(t.leaves))
+	move := func(i int32) int32 {
+		if i == 0 {
+			return 0
+		}
+		c.leaves = append(c.leaves, t.leaves[i])
+		remap[i] = int32(len(c.leaves) - 1)
+		return remap[i]
+	}
+
+	type item struct{ old, new int32 }
+	// reserve a slot for the given node and queue it up
+	var queue []item
+	reserve := func(ni int32) int32 {
+		c.nodes = append(c.nodes, node{})
+		queue = append(queue, item{ni, int32(len(c.nodes) - 1)})
+		return int32(len(c.nodes) - 1)
+	}
+
+	kids := make([]int32, 0, children)
+	reserve(0)
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+
+		nd := &t.nodes[it.old]
+		n := node{
+			edge:    intern(nd.edge),
+			indices: intern(nd.indices),
+			leaf:    move(nd.leaf),
+			prefix:  move(nd.prefix),
+		}
+		if len(nd.children) > 0 {
+			k := len(kids)
+			for _, ci := range nd.children {
+				kids = append(kids, reserve(ci))
+			}
+			// the capacity is limited so that an append to the
+			// children cannot overwrite those of the next node
+			n.children = kids[k:len(kids):len(kids)]
+		}
+		if nd.param != 0 {
+			p := t.params[nd.param]
+			p.leaf = move(p.leaf)
+			if p.child != 0 {
+				p.child = reserve(p.child)
+			}
+			c.params = append(c.params, p)
+			n.param = int32(len(c.params) - 1)
+		}
+		if nd.catchall != 0 {
+			ca := t.catchalls[nd.catchall]
+			ca.leaf = move(ca.leaf)
+			c.catchalls = append(c.catchalls, ca)
+			n.catchall = int32(len(c.catchalls) - 1)
+		}
+		c.nodes[it.new] = n
+	}
+	*t = c
+	return remap
+}
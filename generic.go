@@ -0,0 +1,74 @@
+package route
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// parsers holds the parsers registered with RegisterParser.
+var parsers struct {
+	sync.RWMutex
+	m map[reflect.Type]func(string) (interface{}, error)
+}
+
+// RegisterParser registers the given function as the parser of parameter values
+// of type T. The parser is used by Param, MustParam, Get and Params.Decode and it
+// takes precedence over the built-in parsing of T, which makes it possible to
+// support custom types, e.g. UUIDs or enums, or to override the parsing of the
+// built-in types. Registering a parser for the same type again replaces it.
+func RegisterParser[T any](parse func(string) (T, error)) {
+	if parse == nil {
+		panic("route.RegisterParser: nil parser")
+	}
+
+	parsers.Lock()
+	defer parsers.Unlock()
+	if parsers.m == nil {
+		parsers.m = make(map[reflect.Type]func(string) (interface{}, error))
+	}
+	parsers.m[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (interface{}, error) {
+		return parse(s)
+	}
+}
+
+// lookupParser returns the parser registered for the type t, or nil.
+func lookupParser(t reflect.Type) func(string) (interface{}, error) {
+	parsers.RLock()
+	defer parsers.RUnlock()
+	return parsers.m[t]
+}
+
+// Get returns the value associated with the given key in ps parsed into a T. If
+// there is no value associated with the key, or it cannot be parsed into a T an
+// error of type *ParamError will be returned. Besides the types registered with
+// RegisterParser, Get supports the same types as Params.Decode, time.Time values
+// are parsed using the time.RFC3339 layout.
+func Get[T any](ps Params, key string) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	s, ok := ps.get(key)
+	if !ok {
+		return v, noParam(key, rv.Type().String())
+	}
+	err := decodeValue(rv, s, tagOpts{layout: time.RFC3339})
+	return v, paramError(key, s, rv.Type().String(), err)
+}
+
+// Param returns the value associated with the given key in the Params stored
+// in c, parsed into a T. See Get for more info.
+func Param[T any](c context.Context, key string) (T, error) {
+	return Get[T](GetParams(c), key)
+}
+
+// MustParam is like Param but panics with the *ParamError if the value is missing
+// or cannot be parsed. See Router.SetParamErrorHandler for turning such panics into
+// responses automatically, and HandleParamError for doing the same without a panic.
+func MustParam[T any](c context.Context, key string) T {
+	v, err := Param[T](c, key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testColor int
+
+const (
+	testRed testColor = iota + 1
+	testGreen
+)
+
+func parseTestColor(s string) (testColor, error) {
+	switch strings.ToLower(s) {
+	case "red":
+		return testRed, nil
+	case "green":
+		return testGreen, nil
+	}
+	return 0, fmt.Errorf("invalid color %q", s)
+}
+
+func TestGet(t *testing.T) {
+	RegisterParser(parseTestColor)
+
+	ps := NewParams("id", "42", "color", "Green", "bad", "x", "ratio", "0.5")
+	c := Context(context.Background(), ps)
+
+	id, err := Param[int64](c, "id")
+	equals(t, 0, id, int64(42))
+	equals(t, 1, err, nil)
+
+	color, err := Param[testColor](c, "color")
+	equals(t, 2, color, testGreen)
+	equals(t, 3, err, nil)
+
+	ratio := MustParam[float32](c, "ratio")
+	equals(t, 4, ratio, float32(0.5))
+
+	_, err = Get[testColor](ps, "bad")
+	equals(t, 5, err, &ParamError{"bad", "x", "route.testColor", fmt.Errorf("invalid color %q", "x")})
+
+	_, err = Get[uint8](ps, "missing")
+	equals(t, 6, err, &ParamError{"missing", "", "uint8", ErrNoParam("missing")})
+
+	_, err = Get[int](ps, "bad")
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("got err %v, want %v", err, strconv.ErrSyntax)
+	}
+
+	var dst struct {
+		Color *testColor `route:"color"`
+	}
+	if err := ps.Decode(&dst); err != nil || dst.Color == nil || *dst.Color != testGreen {
+		t.Errorf("Decode with registered parser: got %v, %v", dst.Color, err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("MustParam should panic on a missing param")
+			}
+		}()
+		MustParam[string](c, "missing")
+	}()
+}
+
+type testOptional struct {
+	val string
+}
+
+func TestGet_NilValue(t *testing.T) {
+	RegisterParser(func(s string) (*testOptional, error) {
+		if s == "none" {
+			return nil, nil
+		}
+		return &testOptional{s}, nil
+	})
+
+	ps := NewParams("a", "none", "b", "foo")
+	a, err := Get[*testOptional](ps, "a")
+	equals(t, 0, a, (*testOptional)(nil))
+	equals(t, 1, err, nil)
+
+	b, err := Get[*testOptional](ps, "b")
+	equals(t, 2, b, &testOptional{"foo"})
+	equals(t, 3, err, nil)
+
+	dst := struct {
+		A *testOptional `route:"a"`
+	}{A: &testOptional{"bar"}}
+	err = ps.Decode(&dst)
+	equals(t, 4, dst.A, (*testOptional)(nil))
+	equals(t, 5, err, nil)
+}
@@ -0,0 +1,210 @@
+package route
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteTree writes a textual dump of the Router's routing tree, and the trees of
+// its sub-routers, to w. Each line describes a node, indented by its depth. Static
+// nodes are described by their edge and indices, i.e. the first bytes of their
+// static children's edges, param nodes by their name and the separators that
+// delimit the param's segment, and catch-all nodes by their name. Nodes with
+// Routes are followed by their pattern and the methods of their Routes.
+// The format of the output is meant for debugging and may change between versions.
+func (r *Router) WriteTree(w io.Writer) error {
+	tw := &treeWriter{w: w}
+	r.writeTree(tw, 0)
+	return tw.err
+}
+
+// WriteDOT writes the Router's routing tree, and the trees of its sub-routers,
+// to w in the Graphviz DOT language, with the same information as WriteTree.
+// The nodes with Routes have a double border, the edges to param and catch-all
+// nodes are dashed, and the trees of the sub-routers are drawn in clusters
+// labeled with their host patterns. The output can be rendered with e.g.
+//
+//	dot -Tsvg -o tree.svg tree.dot
+func (r *Router) WriteDOT(w io.Writer) error {
+	tw := &treeWriter{w: w}
+	tw.printf("digraph route {\n")
+	tw.printf("\tnode [shape=box, fontname=\"monospace\"];\n")
+	r.writeDOT(tw, "\t")
+	tw.printf("}\n")
+	return tw.err
+}
+
+// treeWriter writes the dumps of the trees, it keeps the first write error
+// and skips the writes that follow it.
+type treeWriter struct {
+	w   io.Writer
+	err error
+	ids int // the number of DOT nodes written so far
+}
+
+func (tw *treeWriter) printf(format string, args ...interface{}) {
+	if tw.err == nil {
+		_, tw.err = fmt.Fprintf(tw.w, format, args...)
+	}
+}
+
+// The hostnames method returns the sorted host patterns of the Router's sub-routers.
+func (r *Router) hostnames() []string {
+	hosts := make([]string, 0, len(r.subs))
+	for h := range r.subs {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// The writeTree method writes the dump of the Router's trees, indented by the given depth.
+func (r *Router) writeTree(tw *treeWriter, depth int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tree.nodes) > 0 {
+		tw.tree(&r.tree, 0, depth)
+	}
+	for _, h := range r.hostnames() {
+		tw.printf("%shost %q\n", strings.Repeat("  ", depth), h)
+		r.subs[h].writeTree(tw, depth+1)
+	}
+}
+
+// The tree method writes the dump of the subtree rooted at the node ni
+// of the tree t, the node being at the given depth.
+func (tw *treeWriter) tree(t *tree[nodeHandler], ni int32, depth int) {
+	nd := &t.nodes[ni]
+	indent := strings.Repeat("  ", depth)
+	tw.printf("%s%s%s\n", indent, nd.describe(), describeLeaf(t, nd.leaf))
+	for _, ci := range nd.children {
+		tw.tree(t, ci, depth+1)
+	}
+	if nd.param != 0 {
+		p := &t.params[nd.param]
+		tw.printf("%s  %s%s\n", indent, p.describe(), describeLeaf(t, p.leaf))
+		if p.child != 0 {
+			tw.tree(t, p.child, depth+2)
+		}
+	}
+	if nd.catchall != 0 {
+		c := &t.catchalls[nd.catchall]
+		tw.printf("%s  %s%s\n", indent, c.describe(), describeLeaf(t, c.leaf))
+	}
+}
+
+// The writeDOT method writes the DOT statements of the Router's trees, each
+// statement prefixed with the given indentation.
+func (r *Router) writeDOT(tw *treeWriter, indent string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tree.nodes) > 0 {
+		tw.dot(&r.tree, 0, indent)
+	}
+	for i, h := range r.hostnames() {
+		tw.printf("%ssubgraph cluster_%d {\n", indent, i)
+		tw.printf("%s\tlabel=%s;\n", indent, dotQuote("host "+h))
+		r.subs[h].writeDOT(tw, indent+"\t")
+		tw.printf("%s}\n", indent)
+	}
+}
+
+// The dot method writes the DOT statements of the subtree rooted at the
+// node ni of the tree t and returns the DOT identifier of the node.
+func (tw *treeWriter) dot(t *tree[nodeHandler], ni int32, indent string) string {
+	nd := &t.nodes[ni]
+	id := tw.dotNode(indent, nd.describe(), t, nd.leaf, "box")
+	for i, ci := range nd.children {
+		cid := tw.dot(t, ci, indent)
+		tw.printf("%s%s -> %s [label=%s];\n", indent, id, cid, dotQuote(nd.indices[i:i+1]))
+	}
+	if nd.param != 0 {
+		p := &t.params[nd.param]
+		pid := tw.dotNode(indent, p.describe(), t, p.leaf, "ellipse")
+		tw.printf("%s%s -> %s [style=dashed];\n", indent, id, pid)
+		if p.child != 0 {
+			cid := tw.dot(t, p.child, indent)
+			tw.printf("%s%s -> %s;\n", indent, pid, cid)
+		}
+	}
+	if nd.catchall != 0 {
+		c := &t.catchalls[nd.catchall]
+		cid := tw.dotNode(indent, c.describe(), t, c.leaf, "diamond")
+		tw.printf("%s%s -> %s [style=dashed];\n", indent, id, cid)
+	}
+	return id
+}
+
+// dotNode writes the DOT statement of a node with the given description,
+// leaf of the tree t and shape, and returns the node's DOT identifier.
+func (tw *treeWriter) dotNode(indent, desc string, t *tree[nodeHandler], lf int32, shape string) string {
+	id := fmt.Sprintf("n%d", tw.ids)
+	tw.ids++
+
+	lines, attrs := []string{desc}, ""
+	if lf != 0 {
+		l := &t.leaves[lf]
+		lines = append(lines, l.pattern, l.value.methodSet())
+		attrs = ", peripheries=2"
+	}
+	tw.printf("%s%s [label=%s, shape=%s%s];\n", indent, id, dotQuote(lines...), shape, attrs)
+	return id
+}
+
+// dotQuote returns the given lines as a quoted DOT string.
+func dotQuote(lines ...string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for i, l := range lines {
+		lines[i] = r.Replace(l)
+	}
+	return `"` + strings.Join(lines, `\n`) + `"`
+}
+
+// describe returns the description of the static node used by the tree dumps.
+func (nd *node) describe() string {
+	return fmt.Sprintf("%q indices=%q", nd.edge, nd.indices)
+}
+
+// describe returns the description of the param node used by the tree dumps.
+func (p *paramNode) describe() string {
+	return fmt.Sprintf("{%s} start=%q end=%q", p.name, separator(p.start), separator(p.end))
+}
+
+// describe returns the description of the catch-all node used by the tree dumps.
+func (c *catchallNode) describe() string {
+	return "*" + c.name
+}
+
+// describeLeaf returns the description of the leaf lf of the tree t used by
+// the tree dumps, or an empty string if lf is 0.
+func describeLeaf(t *tree[nodeHandler], lf int32) string {
+	if lf == 0 {
+		return ""
+	}
+	l := &t.leaves[lf]
+	return fmt.Sprintf(" pattern=%q methods=%s", l.pattern, l.value.methodSet())
+}
+
+// methodSet returns the comma separated methods of the node handler's
+// Routes, with "*" being first if there is a Route for any method.
+func (nh *nodeHandler) methodSet() string {
+	if nh.any == nil {
+		return nh.methods
+	} else if nh.methods == "" {
+		return "*"
+	}
+	return "*," + nh.methods
+}
+
+// separator returns the param separator b as a string, which is
+// empty if the param has no separator.
+func separator(b byte) string {
+	if b == 0 {
+		return ""
+	}
+	return string(b)
+}
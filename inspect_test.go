@@ -0,0 +1,122 @@
+package route
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouterWriteTree(t *testing.T) {
+	//t.Skip()
+	want := `"" indices="/"
+  "/" indices="us" pattern="/" methods=GET
+    "users" indices="/" pattern="/users" methods=GET,POST
+      "/" indices=""
+        {id} start="/" end="/" pattern="/users/{id}" methods=GET
+          "" indices="/"
+            "/posts/" indices=""
+              {post} start="/" end="" pattern="/users/{id}/posts/{post}" methods=GET
+    "static/" indices=""
+      *file pattern="/static/*file" methods=GET
+  {sub} start="" end="."
+    "" indices="."
+      ".example.com/events" indices="" pattern="{sub}.example.com/events" methods=GET
+host "api.example.com"
+  "" indices="/"
+    "/v1/ping" indices="" pattern="/v1/ping" methods=*
+`
+	for i, freeze := range []bool{false, true} {
+		router := statsRouter()
+		if freeze {
+			router.Freeze()
+		}
+
+		var sb strings.Builder
+		if err := router.WriteTree(&sb); err != nil {
+			t.Fatal(err)
+		}
+		equals(t, i, sb.String(), want)
+	}
+}
+
+func TestRouterWriteDOT(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/a", "h"},
+		{"*", "/a/{b}", "h"},
+		{"GET", "/files/*path", "h"},
+	}.Router()
+	router.Host("api.example.com").Handle("POST", "/x", strHandler("h"))
+
+	want := `digraph route {
+	node [shape=box, fontname="monospace"];
+	n0 [label="\"\" indices=\"/\"", shape=box];
+	n1 [label="\"/\" indices=\"af\"", shape=box];
+	n2 [label="\"a\" indices=\"/\"\n/a\nGET", shape=box, peripheries=2];
+	n3 [label="\"/\" indices=\"\"", shape=box];
+	n4 [label="{b} start=\"/\" end=\"\"\n/a/{b}\n*", shape=ellipse, peripheries=2];
+	n3 -> n4 [style=dashed];
+	n2 -> n3 [label="/"];
+	n1 -> n2 [label="a"];
+	n5 [label="\"files/\" indices=\"\"", shape=box];
+	n6 [label="*path\n/files/*path\nGET", shape=diamond, peripheries=2];
+	n5 -> n6 [style=dashed];
+	n1 -> n5 [label="f"];
+	n0 -> n1 [label="/"];
+	subgraph cluster_0 {
+		label="host api.example.com";
+		n7 [label="\"\" indices=\"/\"", shape=box];
+		n8 [label="\"/x\" indices=\"\"\n/x\nPOST", shape=box, peripheries=2];
+		n7 -> n8 [label="/"];
+	}
+}
+`
+	var sb strings.Builder
+	if err := router.WriteDOT(&sb); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 0, sb.String(), want)
+}
+
+func TestRouterWriteEmptyTree(t *testing.T) {
+	//t.Skip()
+	router := NewRouter()
+	router.Host("api.example.com")
+
+	tests := []struct {
+		router *Router
+		tree   string
+		dot    string
+	}{{
+		router: NewRouter(),
+		tree:   "",
+		dot: `digraph route {
+	node [shape=box, fontname="monospace"];
+}
+`,
+	}, {
+		router: router,
+		tree: `host "api.example.com"
+`,
+		dot: `digraph route {
+	node [shape=box, fontname="monospace"];
+	subgraph cluster_0 {
+		label="host api.example.com";
+	}
+}
+`,
+	}}
+
+	for i, tt := range tests {
+		var sb strings.Builder
+		if err := tt.router.WriteTree(&sb); err != nil {
+			t.Fatal(err)
+		}
+		equals(t, i, sb.String(), tt.tree)
+
+		sb.Reset()
+		if err := tt.router.WriteDOT(&sb); err != nil {
+			t.Fatal(err)
+		}
+		equals(t, i, sb.String(), tt.dot)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package route
+
+// raceEnabled is set if the tests are run with the race detector, which
+// instruments the allocations counted by the zero-alloc tests.
+const raceEnabled = false
@@ -0,0 +1,93 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Normalizer maps concrete URLs to the patterns that match them, e.g. the URL
+// "/users/123/posts/9" to the pattern "/users/{id}/posts/{pid}", using the same
+// matching rules as the Router. The patterns make labels of low cardinality for
+// the metrics and logs of HTTP clients and servers, e.g. for the outgoing URLs
+// of an API client, or for the URLs read from access logs.
+//
+// A Normalizer is safe for concurrent use, provided that the Router it is
+// built from, if any, is not modified concurrently.
+type Normalizer struct {
+	r *Router
+}
+
+// NewNormalizer returns a Normalizer that matches URLs against the given
+// patterns, which are written the same way as the patterns of a Router and
+// may include a host. NewNormalizer returns an error if a pattern is
+// malformed or if it conflicts with one of the previous patterns. The
+// patterns that are repeated are ignored.
+func NewNormalizer(patterns ...string) (*Normalizer, error) {
+	r := NewRouter()
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return nil, errors.New("route: empty pattern")
+		}
+		rt := &Route{Pattern: pattern, Methods: []string{"*"}, handler: r.handle404}
+		if err := r.insert(rt); err != nil {
+			if e, ok := err.(*routeError); ok && e.typ == errMethodConflict {
+				continue
+			}
+			return nil, fmt.Errorf("route: %s: %w", pattern, err)
+		}
+	}
+	return &Normalizer{r: r}, nil
+}
+
+// Normalizer returns a Normalizer that matches URLs against the patterns of
+// the Router and its sub-routers, including the patterns registered after
+// the Normalizer is returned. The URLs are matched regardless of the methods
+// with which the patterns are registered and of the validation rules of their
+// params, and the Router's handlers are never called.
+func (r *Router) Normalizer() *Normalizer {
+	return &Normalizer{r: r}
+}
+
+// Normalize returns the pattern that matches the URL, which is either an
+// absolute URL, e.g. "https://api.example.com/users/123", or an absolute
+// path with an optional query, e.g. "/users/123?tab=posts", and reports
+// whether a pattern matched the URL. The URL does not match any pattern if
+// it cannot be parsed, or if the Router would redirect it to the same URL
+// with or without a trailing slash.
+func (n *Normalizer) Normalize(rawURL string) (pattern string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	return n.NormalizeURL(u)
+}
+
+// NormalizeURL is like Normalize but it takes a parsed URL, e.g. the URL of
+// an outgoing request. The URL's host is matched against the host patterns,
+// a URL without a host matches only the patterns without one.
+func (n *Normalizer) NormalizeURL(u *url.URL) (pattern string, ok bool) {
+	c := n.r.ctxpool.Get().(*ctx)
+	pattern, ok = n.r.match(u.Host, u, c.Params[:0])
+	n.r.ctxpool.Put(c)
+	return pattern, ok
+}
+
+// The match method returns the pattern that matches the given host and the
+// path of the URL the same way the route method resolves it, except that
+// the request's method is not consulted. The po Params are used only as
+// the lookup's buffer.
+func (r *Router) match(host string, u *url.URL, po Params) (string, bool) {
+	if r.vhosts != nil {
+		if sub, hps := r.vhost(host, po, nil); sub != nil {
+			return sub.match(host, u, hps)
+		}
+	}
+
+	path := u.Path
+	if r.rawPath {
+		path = u.EscapedPath()
+	}
+	nh, _, pat, _, _ := r.resolve(host, path, po, nil)
+	return pat, nh != nil
+}
@@ -0,0 +1,131 @@
+package route
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestNewNormalizer(t *testing.T) {
+	//t.Skip()
+	n, err := NewNormalizer(
+		"/users",
+		"/users/{id}",
+		"/users/{id}/posts/{pid}",
+		"/users/{id}",
+		"/files/{name}.{ext}",
+		"/static/*file",
+		"/tags/{tags...}",
+		"/dir/",
+		"api.example.com/v1/{res}",
+		"{sub}.example.com/events",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url     string
+		pattern string
+		ok      bool
+	}{
+		{"/users", "/users", true},
+		{"/users/123", "/users/{id}", true},
+		{"/users/123/posts/9", "/users/{id}/posts/{pid}", true},
+		{"/users/123/posts/9?tab=comments#top", "/users/{id}/posts/{pid}", true},
+		{"https://other.com/users/123", "/users/{id}", true},
+		{"/users/j%2Fdoe", "", false}, // matched unescaped, like the Router
+		{"/users/j%20doe", "/users/{id}", true},
+		{"/files/app.min.js", "/files/{name}.{ext}", true},
+		{"/static/css/app.css", "/static/*file", true},
+		{"/tags/go/http", "/tags/{tags...}", true},
+		{"https://api.example.com/v1/orders", "api.example.com/v1/{res}", true},
+		{"/v1/orders", "", false},
+		{"http://www.example.com/events", "{sub}.example.com/events", true},
+		{"/users/123/posts", "", false},
+		{"/users/", "", false}, // redirected to /users
+		{"/dir", "", false},    // redirected to /dir/
+		{"/nothing", "", false},
+		{"%zz", "", false},
+	}
+	for i, tt := range tests {
+		pattern, ok := n.Normalize(tt.url)
+		equals(t, i, pattern, tt.pattern)
+		equals(t, i, ok, tt.ok)
+	}
+
+	for i, tt := range []struct {
+		patterns []string
+		err      string
+	}{
+		{[]string{"/users/{id}", "/users/{name}"}, `route: /users/{name}: The param name "name" conflicts with the param name "id" in the same segment of a previously registered pattern.`},
+		{[]string{"/users/{id"}, "route: /users/{id: missing closing curly brace '}'"},
+		{[]string{"/a", ""}, "route: empty pattern"},
+	} {
+		_, err := NewNormalizer(tt.patterns...)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("#%d: got error %v, want %s", i, err, tt.err)
+		}
+	}
+}
+
+func TestRouterNormalizer(t *testing.T) {
+	//t.Skip()
+	router := NewRouter()
+	router.Handle("GET", "/users/{id}", nopHandler{})
+	router.Handle("POST", "/orders/{id}/items", nopHandler{})
+	router.Handle("GET", "/raw/{name}", nopHandler{}).WithRules("name", MaxLen(2))
+	sub := router.Host("{tenant}.example.com")
+	sub.Handle("GET", "/dashboard/{tab}", nopHandler{})
+
+	n := router.Normalizer()
+	router.Handle("GET", "/late/{id}", nopHandler{})
+
+	tests := []struct {
+		url     string
+		pattern string
+		ok      bool
+	}{
+		{"/users/1", "/users/{id}", true},
+		{"/orders/1/items", "/orders/{id}/items", true},
+		{"/raw/too-long", "/raw/{name}", true},
+		{"/late/1", "/late/{id}", true},
+		{"https://acme.example.com/dashboard/billing", "/dashboard/{tab}", true},
+		{"https://acme.example.com/users/1", "", false},
+		{"/dashboard/billing", "", false},
+	}
+	for i, tt := range tests {
+		pattern, ok := n.Normalize(tt.url)
+		equals(t, i, pattern, tt.pattern)
+		equals(t, i, ok, tt.ok)
+	}
+}
+
+// The Normalizer must resolve the same patterns as the Router's Handler.
+func TestNormalizer_Router(t *testing.T) {
+	//t.Skip()
+	for _, routes := range [][]benchRoute{staticRoutes, githubRoutes, parseRoutes} {
+		router := benchRouter(routes)
+		var patterns []string
+		for _, rt := range routes {
+			patterns = append(patterns, rt.path)
+		}
+		n, err := NewNormalizer(patterns...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, rt := range routes {
+			p := benchPath(rt.path)
+			for _, path := range []string{p, p + "/", p + "x", p + "/x", "/x" + p} {
+				u := &url.URL{Path: path}
+				_, _, want := router.Handler(mustNewRequest(rt.method, path, nil))
+				for _, got := range []*Normalizer{n, router.Normalizer()} {
+					pattern, ok := got.NormalizeURL(u)
+					if pattern != want || ok != (want != "") {
+						t.Errorf("%s: got (%q, %t), want %q", path, pattern, ok, want)
+					}
+				}
+			}
+		}
+	}
+}
@@ -1,17 +1,31 @@
 package route
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type param struct {
 	key string
 	val string
+	raw string // the escaped value, if different from val
 }
 
-// ErrNoParam is returned by any of the Params' methods when no param for the specified key is found.
+var (
+	// ErrParamMissing matches, using errors.Is, the errors of parameters that are missing.
+	ErrParamMissing = errors.New("route: param missing")
+	// ErrParamMalformed matches, using errors.Is, the errors of parameters that
+	// are present but whose value cannot be parsed into the requested type.
+	ErrParamMalformed = errors.New("route: param malformed")
+)
+
+// ErrNoParam is the underlying error of a ParamError when no param for the specified key is found.
 type ErrNoParam string
 
 // Error implements the error interface.
@@ -19,6 +33,59 @@ func (e ErrNoParam) Error() string {
 	return "no param for " + string(e)
 }
 
+// Is reports whether target is ErrParamMissing.
+func (e ErrNoParam) Is(target error) bool {
+	return target == ErrParamMissing
+}
+
+// ParamError is returned by any of the Params' methods, as well as by Get, Param and
+// Decode, when the value of a parameter is missing or cannot be parsed into the
+// requested type. Use errors.Is with ErrParamMissing or ErrParamMalformed to tell
+// the two cases apart.
+type ParamError struct {
+	Key   string // the parameter's key
+	Value string // the parameter's raw value, empty if the parameter is missing
+	Type  string // the type into which the value was to be parsed, e.g. "int"
+	Err   error  // the underlying error, an ErrNoParam if the parameter is missing
+}
+
+// Error implements the error interface.
+func (e *ParamError) Error() string {
+	if e.missing() {
+		return fmt.Sprintf("route: missing param %q", e.Key)
+	}
+	return fmt.Sprintf("route: cannot parse param %s=%q into %s: %v", e.Key, e.Value, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrParamMalformed and the parameter is not missing.
+// Whether the parameter is missing is reported through the underlying ErrNoParam.
+func (e *ParamError) Is(target error) bool {
+	return target == ErrParamMalformed && !e.missing()
+}
+
+func (e *ParamError) missing() bool {
+	_, ok := e.Err.(ErrNoParam)
+	return ok
+}
+
+// paramError returns a *ParamError for the given err, or nil if err is nil.
+func paramError(key, val, typ string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParamError{Key: key, Value: val, Type: typ, Err: err}
+}
+
+// noParam returns a *ParamError for the missing key.
+func noParam(key, typ string) *ParamError {
+	return &ParamError{Key: key, Type: typ, Err: ErrNoParam(key)}
+}
+
 // Params holds the URL parameters of a single request with the keys matching
 // the names specified in the pattern during Handler registration e.g. "/posts/{post_id}".
 // Params also provides a number of convenience methods to parse the parameter values into other types.
@@ -45,6 +112,154 @@ func NewParams(kv ...string) (out Params) {
 	return out
 }
 
+// Len returns the number of parameters in ps.
+func (ps Params) Len() int {
+	return len(ps)
+}
+
+// Has reports whether ps holds a parameter with the given key.
+func (ps Params) Has(key string) bool {
+	_, ok := ps.get(key)
+	return ok
+}
+
+// Keys returns the keys of the parameters in ps in the order in which
+// they appear in the matched pattern.
+func (ps Params) Keys() []string {
+	keys := make([]string, len(ps))
+	for i, p := range ps {
+		keys[i] = p.key
+	}
+	return keys
+}
+
+// Range calls fn for each parameter in ps in the order in which they
+// appear in the matched pattern. If fn returns false Range stops.
+func (ps Params) Range(fn func(key, val string) bool) {
+	for _, p := range ps {
+		if !fn(p.key, p.val) {
+			return
+		}
+	}
+}
+
+// Map returns the parameters in ps as a map of keys to values.
+func (ps Params) Map() map[string]string {
+	m := make(map[string]string, len(ps))
+	for _, p := range ps {
+		m[p.key] = p.val
+	}
+	return m
+}
+
+// With returns a copy of ps in which the given key is associated with the
+// given value, the value replaces that of an existing parameter with the same
+// key, otherwise the parameter is appended. The receiver is not modified.
+func (ps Params) With(key, val string) Params {
+	out := make(Params, len(ps), len(ps)+1)
+	copy(out, ps)
+	for i := range out {
+		if out[i].key == key {
+			out[i].val = val
+			return out
+		}
+	}
+	return append(out, param{key: key, val: val})
+}
+
+// Without returns a copy of ps without the parameters with the given
+// key. The receiver is not modified.
+func (ps Params) Without(key string) Params {
+	out := make(Params, 0, len(ps))
+	for _, p := range ps {
+		if p.key != key {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Clone returns a copy of ps. Since the Params passed to a Handler are reused by
+// the Router once the Handler returns, a Handler that retains its Params, e.g. in
+// a goroutine, should retain a clone.
+func (ps Params) Clone() Params {
+	if ps == nil {
+		return nil
+	}
+	out := make(Params, len(ps))
+	copy(out, ps)
+	return out
+}
+
+// Format implements the fmt.Formatter interface. Params are formatted as a list of
+// key=value pairs in the order in which they appear in the matched pattern, e.g.
+// "[post_id=123 slug=hello]", the %q verb quotes the values. Since the String method
+// is reserved for accessing parameter values, Params do not implement fmt.Stringer.
+func (ps Params) Format(f fmt.State, verb rune) {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, p := range ps {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.key)
+		b.WriteByte('=')
+		if verb == 'q' {
+			b.WriteString(strconv.Quote(p.val))
+		} else {
+			b.WriteString(p.val)
+		}
+	}
+	b.WriteByte(']')
+	io.WriteString(f, b.String())
+}
+
+// MarshalJSON implements the json.Marshaler interface. Params are encoded as
+// a JSON object whose members are in the order in which the parameters appear
+// in the matched pattern. The values of a key that appears more than once, e.g.
+// in a host and a path pattern, are encoded as an array in the member of the
+// key's first appearance.
+func (ps Params) MarshalJSON() ([]byte, error) {
+	if ps == nil {
+		return []byte("null"), nil
+	}
+
+	b := []byte{'{'}
+	for i, p := range ps {
+		first, vals := true, []string(nil)
+		for j := range ps {
+			if ps[j].key == p.key {
+				first = first && j >= i
+				vals = append(vals, ps[j].val)
+			}
+		}
+		if !first {
+			continue // encoded with the key's first appearance
+		}
+
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		k, err := json.Marshal(p.key)
+		if err != nil {
+			return nil, err
+		}
+		var v []byte
+		if len(vals) > 1 {
+			v, err = json.Marshal(vals)
+		} else {
+			v, err = json.Marshal(p.val)
+		}
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, k...)
+		b = append(b, ':')
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
+}
+
 func (ps Params) get(key string) (string, bool) {
 	for _, p := range ps {
 		if p.key == key {
@@ -54,13 +269,36 @@ func (ps Params) get(key string) (string, bool) {
 	return "", false
 }
 
+// Raw returns the escaped value associated with the given key, as it appeared in the
+// request's URL. Raw values are available only if the Router matches requests on the
+// escaped path, see Router.SetRawPath, otherwise Raw returns the same value as String.
+// If there is no value associated with the key an error will be returned.
+func (ps Params) Raw(key string) (string, error) {
+	for _, p := range ps {
+		if p.key == key {
+			if p.raw != "" {
+				return p.raw, nil
+			}
+			return p.val, nil
+		}
+	}
+	return "", noParam(key, "string")
+}
+
+// GetRaw is a "convenience" wrapper around Raw that ignores errors.
+func (ps Params) GetRaw(key string) string {
+	v, _ := ps.Raw(key)
+	return v
+}
+
 // Bool returns the value associated with the given key parsed into a bool. If there is no value
 // associated with the key, or it cannot be parsed into a bool an error will be returned.
 func (ps Params) Bool(key string) (bool, error) {
 	if v, ok := ps.get(key); ok {
-		return strconv.ParseBool(v)
+		b, err := strconv.ParseBool(v)
+		return b, paramError(key, v, "bool", err)
 	}
-	return false, ErrNoParam(key)
+	return false, noParam(key, "bool")
 }
 
 // GetBool is a "convenience" wrapper around Bool that ignores errors.
@@ -75,7 +313,7 @@ func (ps Params) String(key string) (string, error) {
 	if v, ok := ps.get(key); ok {
 		return v, nil
 	}
-	return "", ErrNoParam(key)
+	return "", noParam(key, "string")
 }
 
 // GetString is a "convenience" wrapper around String that ignores errors.
@@ -89,9 +327,9 @@ func (ps Params) GetString(key string) string {
 func (ps Params) Int(key string) (int, error) {
 	if v, ok := ps.get(key); ok {
 		i64, err := strconv.ParseInt(v, 10, 64)
-		return int(i64), err
+		return int(i64), paramError(key, v, "int", err)
 	}
-	return 0, ErrNoParam(key)
+	return 0, noParam(key, "int")
 }
 
 // GetInt is a "convenience" wrapper around Int that ignores errors.
@@ -104,9 +342,10 @@ func (ps Params) GetInt(key string) int {
 // associated with the key, or it cannot be parsed into an int64 an error will be returned.
 func (ps Params) Int64(key string) (int64, error) {
 	if v, ok := ps.get(key); ok {
-		return strconv.ParseInt(v, 10, 64)
+		i64, err := strconv.ParseInt(v, 10, 64)
+		return i64, paramError(key, v, "int64", err)
 	}
-	return 0, ErrNoParam(key)
+	return 0, noParam(key, "int64")
 }
 
 // GetInt64 is a "convenience" wrapper around Int that ignores errors.
@@ -120,9 +359,9 @@ func (ps Params) GetInt64(key string) int64 {
 func (ps Params) Uint(key string) (uint, error) {
 	if v, ok := ps.get(key); ok {
 		u64, err := strconv.ParseUint(v, 10, 64)
-		return uint(u64), err
+		return uint(u64), paramError(key, v, "uint", err)
 	}
-	return 0, ErrNoParam(key)
+	return 0, noParam(key, "uint")
 }
 
 // GetUint is a "convenience" wrapper around Uint that ignores errors.
@@ -135,9 +374,10 @@ func (ps Params) GetUint(key string) uint {
 // associated with the key, or it cannot be parsed into a uint64 an error will be returned.
 func (ps Params) Uint64(key string) (uint64, error) {
 	if v, ok := ps.get(key); ok {
-		return strconv.ParseUint(v, 10, 64)
+		u64, err := strconv.ParseUint(v, 10, 64)
+		return u64, paramError(key, v, "uint64", err)
 	}
-	return 0, ErrNoParam(key)
+	return 0, noParam(key, "uint64")
 }
 
 // GetUint64 is a "convenience" wrapper around Uint that ignores errors.
@@ -150,9 +390,10 @@ func (ps Params) GetUint64(key string) uint64 {
 // associated with the key, or it cannot be parsed into a float64 an error will be returned.
 func (ps Params) Float(key string) (float64, error) {
 	if v, ok := ps.get(key); ok {
-		return strconv.ParseFloat(v, 64)
+		f, err := strconv.ParseFloat(v, 64)
+		return f, paramError(key, v, "float64", err)
 	}
-	return 0.0, ErrNoParam(key)
+	return 0.0, noParam(key, "float64")
 }
 
 // GetFloat is a "convenience" wrapper around Float that ignores errors.
@@ -161,18 +402,46 @@ func (ps Params) GetFloat(key string) float64 {
 	return v
 }
 
+// Segments returns the value associated with the given key, e.g. that of a catch-all
+// or a repeated parameter, as a cleaned path split into its segments. For example the
+// value "go/http//router/" yields []string{"go", "http", "router"}, and an empty value
+// yields nil. If there is no value associated with the key an error will be returned.
+//
+// To parse the segments into other types, e.g. []int, use the generic Get function.
+func (ps Params) Segments(key string) ([]string, error) {
+	if v, ok := ps.get(key); ok {
+		return splitSegments(v), nil
+	}
+	return nil, noParam(key, "[]string")
+}
+
+// GetSegments is a "convenience" wrapper around Segments that ignores errors.
+func (ps Params) GetSegments(key string) []string {
+	v, _ := ps.Segments(key)
+	return v
+}
+
+func splitSegments(v string) []string {
+	v = path.Clean("/" + v)
+	if v == "/" {
+		return nil
+	}
+	return strings.Split(v[1:], "/")
+}
+
 // Time returns the value associated with the given key parsed into a time.Time. The value
 // is parsed using the time.Parse function and the specified layout. If there is no value
 // associated with the key, or it cannot be parsed into a time.Time an error will be returned.
 func (ps Params) Time(key, layout string) (time.Time, error) {
 	if v, ok := ps.get(key); ok {
-		return time.Parse(layout, v)
+		t, err := time.Parse(layout, v)
+		return t, paramError(key, v, "time.Time", err)
 	}
-	return time.Time{}, ErrNoParam(key)
+	return time.Time{}, noParam(key, "time.Time")
 }
 
 // GetTime is a "convenience" wrapper around Time that ignores errors.
 func (ps Params) GetTime(key, layout string) time.Time {
 	v, _ := ps.Time(key, layout)
 	return v
-}
\ No newline at end of file
+}
@@ -0,0 +1,17 @@
+//go:build go1.21
+
+package route
+
+import (
+	"log/slog"
+)
+
+// LogValue implements the slog.LogValuer interface. Params are logged as
+// a group whose attributes are the parameters' keys and values.
+func (ps Params) LogValue() slog.Value {
+	attrs := make([]slog.Attr, len(ps))
+	for i, p := range ps {
+		attrs[i] = slog.String(p.key, p.val)
+	}
+	return slog.GroupValue(attrs...)
+}
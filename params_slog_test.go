@@ -0,0 +1,23 @@
+//go:build go1.21
+
+package route
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestParamsLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("req", "params", NewParams("post_id", "123", "slug", "hello"))
+	equals(t, 0, buf.String(), "level=INFO msg=req params.post_id=123 params.slug=hello\n")
+}
@@ -1,7 +1,13 @@
 package route
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"testing"
@@ -19,6 +25,13 @@ type paramsTest struct {
 }
 
 func (p paramsTest) check(t *testing.T, i int, got interface{}, err error) {
+	if pe, ok := err.(*ParamError); ok {
+		val, _ := p.params.get(testKey)
+		if pe.Key != testKey || pe.Value != val {
+			t.Errorf("#%d: got ParamError{Key: %q, Value: %q}, want {Key: %q, Value: %q}", i, pe.Key, pe.Value, testKey, val)
+		}
+		err = pe.Err
+	}
 	if !reflect.DeepEqual(err, p.err) {
 		t.Errorf("#%d: got err %v, want %v", i, err, p.err)
 	}
@@ -34,7 +47,7 @@ func TestNewParams(t *testing.T) {
 		wantPanic bool
 	}{{
 		args: []string{"foo", "123"},
-		want: Params{{"foo", "123"}},
+		want: Params{{key: "foo", val: "123"}},
 	}, {
 		args: []string{},
 		want: nil,
@@ -43,7 +56,7 @@ func TestNewParams(t *testing.T) {
 		want: nil,
 	}, {
 		args: []string{"foo", "123", "bar", "baz", "aaa", "true", "bbb", "false"},
-		want: Params{{"foo", "123"}, {"bar", "baz"}, {"aaa", "true"}, {"bbb", "false"}},
+		want: Params{{key: "foo", val: "123"}, {key: "bar", val: "baz"}, {key: "aaa", val: "true"}, {key: "bbb", val: "false"}},
 	}, {
 		args:      []string{"foo", "123", "bar", "baz", "aaa", "true", "bbb"},
 		wantPanic: true,
@@ -68,26 +81,26 @@ func TestNewParams(t *testing.T) {
 func TestParamsBool(t *testing.T) {
 	var tests = []paramsTest{
 		// 1, t, T, TRUE, true, True
-		{params: Params{{testKey, "1"}}, want: true, err: nil},
-		{params: Params{{testKey, "t"}}, want: true, err: nil},
-		{params: Params{{testKey, "T"}}, want: true, err: nil},
-		{params: Params{{testKey, "TRUE"}}, want: true, err: nil},
-		{params: Params{{testKey, "true"}}, want: true, err: nil},
-		{params: Params{{testKey, "True"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "1"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "t"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "T"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "TRUE"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "true"}}, want: true, err: nil},
+		{params: Params{{key: testKey, val: "True"}}, want: true, err: nil},
 		// 0, f, F, FALSE, false, False
-		{params: Params{{testKey, "0"}}, want: false, err: nil},
-		{params: Params{{testKey, "f"}}, want: false, err: nil},
-		{params: Params{{testKey, "F"}}, want: false, err: nil},
-		{params: Params{{testKey, "FALSE"}}, want: false, err: nil},
-		{params: Params{{testKey, "false"}}, want: false, err: nil},
-		{params: Params{{testKey, "False"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "f"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "F"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "FALSE"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "false"}}, want: false, err: nil},
+		{params: Params{{key: testKey, val: "False"}}, want: false, err: nil},
 		// no param
 		{params: Params{}, want: false, err: ErrNoParam(testKey)},
-		{params: Params{{"kii", "TRUE"}}, want: false, err: ErrNoParam(testKey)},
+		{params: Params{{key: "kii", val: "TRUE"}}, want: false, err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, "TruE"}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "TruE", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, ""}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "123"}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "123", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "TruE"}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "TruE", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: ""}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "123"}}, want: false, err: &strconv.NumError{Func: "ParseBool", Num: "123", Err: strconv.ErrSyntax}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Bool(testKey)
@@ -97,13 +110,13 @@ func TestParamsBool(t *testing.T) {
 
 func TestParamsString(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, ""}}, want: "", err: nil},
-		{params: Params{{testKey, "  "}}, want: "  ", err: nil},
-		{params: Params{{testKey, "123"}}, want: "123", err: nil},
-		{params: Params{{testKey, "foobar"}}, want: "foobar", err: nil},
+		{params: Params{{key: testKey, val: ""}}, want: "", err: nil},
+		{params: Params{{key: testKey, val: "  "}}, want: "  ", err: nil},
+		{params: Params{{key: testKey, val: "123"}}, want: "123", err: nil},
+		{params: Params{{key: testKey, val: "foobar"}}, want: "foobar", err: nil},
 		// no param
 		{params: Params{}, want: "", err: ErrNoParam(testKey)},
-		{params: Params{{"KEY", "foobar"}}, want: "", err: ErrNoParam(testKey)},
+		{params: Params{{key: "KEY", val: "foobar"}}, want: "", err: ErrNoParam(testKey)},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.String(testKey)
@@ -113,20 +126,20 @@ func TestParamsString(t *testing.T) {
 
 func TestParamsInt(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "0"}}, want: 0, err: nil},
-		{params: Params{{testKey, "12345"}}, want: 12345, err: nil},
-		{params: Params{{testKey, "-12345"}}, want: -12345, err: nil},
-		{params: Params{{testKey, "9223372036854775807"}}, want: 9223372036854775807, err: nil},
-		{params: Params{{testKey, "-9223372036854775808"}}, want: -9223372036854775808, err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: 0, err: nil},
+		{params: Params{{key: testKey, val: "12345"}}, want: 12345, err: nil},
+		{params: Params{{key: testKey, val: "-12345"}}, want: -12345, err: nil},
+		{params: Params{{key: testKey, val: "9223372036854775807"}}, want: 9223372036854775807, err: nil},
+		{params: Params{{key: testKey, val: "-9223372036854775808"}}, want: -9223372036854775808, err: nil},
 		// no param
 		{params: Params{}, want: 0, err: ErrNoParam(testKey)},
-		{params: Params{{"Key", "7"}}, want: 0, err: ErrNoParam(testKey)},
+		{params: Params{{key: "Key", val: "7"}}, want: 0, err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, "9223372036854775808"}}, want: 9223372036854775807, err: &strconv.NumError{Func: "ParseInt", Num: "9223372036854775808", Err: strconv.ErrRange}},
-		{params: Params{{testKey, "-9223372036854775809"}}, want: -9223372036854775808, err: &strconv.NumError{Func: "ParseInt", Num: "-9223372036854775809", Err: strconv.ErrRange}},
-		{params: Params{{testKey, ""}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "twenty two"}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "twenty two", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "22.89"}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "22.89", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "9223372036854775808"}}, want: 9223372036854775807, err: &strconv.NumError{Func: "ParseInt", Num: "9223372036854775808", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: "-9223372036854775809"}}, want: -9223372036854775808, err: &strconv.NumError{Func: "ParseInt", Num: "-9223372036854775809", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: ""}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "twenty two"}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "twenty two", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "22.89"}}, want: 0, err: &strconv.NumError{Func: "ParseInt", Num: "22.89", Err: strconv.ErrSyntax}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Int(testKey)
@@ -136,20 +149,20 @@ func TestParamsInt(t *testing.T) {
 
 func TestParamsInt64(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "0"}}, want: int64(0), err: nil},
-		{params: Params{{testKey, "12345"}}, want: int64(12345), err: nil},
-		{params: Params{{testKey, "-12345"}}, want: int64(-12345), err: nil},
-		{params: Params{{testKey, "9223372036854775807"}}, want: int64(9223372036854775807), err: nil},
-		{params: Params{{testKey, "-9223372036854775808"}}, want: int64(-9223372036854775808), err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: int64(0), err: nil},
+		{params: Params{{key: testKey, val: "12345"}}, want: int64(12345), err: nil},
+		{params: Params{{key: testKey, val: "-12345"}}, want: int64(-12345), err: nil},
+		{params: Params{{key: testKey, val: "9223372036854775807"}}, want: int64(9223372036854775807), err: nil},
+		{params: Params{{key: testKey, val: "-9223372036854775808"}}, want: int64(-9223372036854775808), err: nil},
 		// no param
 		{params: Params{}, want: int64(0), err: ErrNoParam(testKey)},
-		{params: Params{{"Key", "7"}}, want: int64(0), err: ErrNoParam(testKey)},
+		{params: Params{{key: "Key", val: "7"}}, want: int64(0), err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, "9223372036854775808"}}, want: int64(9223372036854775807), err: &strconv.NumError{Func: "ParseInt", Num: "9223372036854775808", Err: strconv.ErrRange}},
-		{params: Params{{testKey, "-9223372036854775809"}}, want: int64(-9223372036854775808), err: &strconv.NumError{Func: "ParseInt", Num: "-9223372036854775809", Err: strconv.ErrRange}},
-		{params: Params{{testKey, ""}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "twenty two"}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "twenty two", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "22.89"}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "22.89", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "9223372036854775808"}}, want: int64(9223372036854775807), err: &strconv.NumError{Func: "ParseInt", Num: "9223372036854775808", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: "-9223372036854775809"}}, want: int64(-9223372036854775808), err: &strconv.NumError{Func: "ParseInt", Num: "-9223372036854775809", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: ""}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "twenty two"}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "twenty two", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "22.89"}}, want: int64(0), err: &strconv.NumError{Func: "ParseInt", Num: "22.89", Err: strconv.ErrSyntax}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Int64(testKey)
@@ -159,18 +172,18 @@ func TestParamsInt64(t *testing.T) {
 
 func TestParamsUint(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "0"}}, want: uint(0), err: nil},
-		{params: Params{{testKey, "356487"}}, want: uint(356487), err: nil},
-		{params: Params{{testKey, "18446744073709551615"}}, want: uint(18446744073709551615), err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: uint(0), err: nil},
+		{params: Params{{key: testKey, val: "356487"}}, want: uint(356487), err: nil},
+		{params: Params{{key: testKey, val: "18446744073709551615"}}, want: uint(18446744073709551615), err: nil},
 		// no param
 		{params: Params{}, want: uint(0), err: ErrNoParam(testKey)},
-		{params: Params{{"u64", "223"}}, want: uint(0), err: ErrNoParam(testKey)},
+		{params: Params{{key: "u64", val: "223"}}, want: uint(0), err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, "18446744073709551616"}}, want: uint(18446744073709551615), err: &strconv.NumError{Func: "ParseUint", Num: "18446744073709551616", Err: strconv.ErrRange}},
-		{params: Params{{testKey, "-1"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "-1", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, ""}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "eleven"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "eleven", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "22.89"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "22.89", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "18446744073709551616"}}, want: uint(18446744073709551615), err: &strconv.NumError{Func: "ParseUint", Num: "18446744073709551616", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: "-1"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "-1", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: ""}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "eleven"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "eleven", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "22.89"}}, want: uint(0), err: &strconv.NumError{Func: "ParseUint", Num: "22.89", Err: strconv.ErrSyntax}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Uint(testKey)
@@ -180,18 +193,18 @@ func TestParamsUint(t *testing.T) {
 
 func TestParamsUint64(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "0"}}, want: uint64(0), err: nil},
-		{params: Params{{testKey, "356487"}}, want: uint64(356487), err: nil},
-		{params: Params{{testKey, "18446744073709551615"}}, want: uint64(18446744073709551615), err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: uint64(0), err: nil},
+		{params: Params{{key: testKey, val: "356487"}}, want: uint64(356487), err: nil},
+		{params: Params{{key: testKey, val: "18446744073709551615"}}, want: uint64(18446744073709551615), err: nil},
 		// no param
 		{params: Params{}, want: uint64(0), err: ErrNoParam(testKey)},
-		{params: Params{{"u64", "223"}}, want: uint64(0), err: ErrNoParam(testKey)},
+		{params: Params{{key: "u64", val: "223"}}, want: uint64(0), err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, "18446744073709551616"}}, want: uint64(18446744073709551615), err: &strconv.NumError{Func: "ParseUint", Num: "18446744073709551616", Err: strconv.ErrRange}},
-		{params: Params{{testKey, "-1"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "-1", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, ""}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "eleven"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "eleven", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "22.89"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "22.89", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "18446744073709551616"}}, want: uint64(18446744073709551615), err: &strconv.NumError{Func: "ParseUint", Num: "18446744073709551616", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: "-1"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "-1", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: ""}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "eleven"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "eleven", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "22.89"}}, want: uint64(0), err: &strconv.NumError{Func: "ParseUint", Num: "22.89", Err: strconv.ErrSyntax}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Uint64(testKey)
@@ -201,20 +214,20 @@ func TestParamsUint64(t *testing.T) {
 
 func TestParamsFloat(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "0"}}, want: float64(0), err: nil},
-		{params: Params{{testKey, "24"}}, want: float64(24), err: nil},
-		{params: Params{{testKey, "1.0"}}, want: float64(1), err: nil},
-		{params: Params{{testKey, "0.000000009"}}, want: float64(0.000000009), err: nil},
-		{params: Params{{testKey, "-1234.456e+78"}}, want: float64(-1234.456e+78), err: nil},
-		{params: Params{{testKey, "1.797693134862315708145274237317043567981e+308"}}, want: math.MaxFloat64, err: nil},
+		{params: Params{{key: testKey, val: "0"}}, want: float64(0), err: nil},
+		{params: Params{{key: testKey, val: "24"}}, want: float64(24), err: nil},
+		{params: Params{{key: testKey, val: "1.0"}}, want: float64(1), err: nil},
+		{params: Params{{key: testKey, val: "0.000000009"}}, want: float64(0.000000009), err: nil},
+		{params: Params{{key: testKey, val: "-1234.456e+78"}}, want: float64(-1234.456e+78), err: nil},
+		{params: Params{{key: testKey, val: "1.797693134862315708145274237317043567981e+308"}}, want: math.MaxFloat64, err: nil},
 		// no param
 		{params: Params{}, want: float64(0), err: ErrNoParam(testKey)},
-		{params: Params{{"ke.y", "12.3"}}, want: float64(0), err: ErrNoParam(testKey)},
+		{params: Params{{key: "ke.y", val: "12.3"}}, want: float64(0), err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, ""}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "zero.one"}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "zero.one", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "0.1.2"}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "0.1.2", Err: strconv.ErrSyntax}},
-		{params: Params{{testKey, "1.797693134862315708145274237317043567981e+309"}}, want: math.Inf(0), err: &strconv.NumError{Func: "ParseFloat", Num: "1.797693134862315708145274237317043567981e+309", Err: strconv.ErrRange}},
+		{params: Params{{key: testKey, val: ""}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "zero.one"}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "zero.one", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "0.1.2"}}, want: float64(0), err: &strconv.NumError{Func: "ParseFloat", Num: "0.1.2", Err: strconv.ErrSyntax}},
+		{params: Params{{key: testKey, val: "1.797693134862315708145274237317043567981e+309"}}, want: math.Inf(0), err: &strconv.NumError{Func: "ParseFloat", Num: "1.797693134862315708145274237317043567981e+309", Err: strconv.ErrRange}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Float(testKey)
@@ -224,19 +237,154 @@ func TestParamsFloat(t *testing.T) {
 
 func TestParamsTime(t *testing.T) {
 	var tests = []paramsTest{
-		{params: Params{{testKey, "1943-09-21"}}, layout: "2006-01-02", want: time.Date(1943, 9, 21, 0, 0, 0, 0, time.UTC), err: nil},
-		{params: Params{{testKey, "1929/04/08"}}, layout: "2006/01/02", want: time.Date(1929, 4, 8, 0, 0, 0, 0, time.UTC), err: nil},
-		{params: Params{{testKey, "1929+04+08+12:24:59"}}, layout: "2006+01+02+15:04:05", want: time.Date(1929, 4, 8, 12, 24, 59, 0, time.UTC), err: nil},
+		{params: Params{{key: testKey, val: "1943-09-21"}}, layout: "2006-01-02", want: time.Date(1943, 9, 21, 0, 0, 0, 0, time.UTC), err: nil},
+		{params: Params{{key: testKey, val: "1929/04/08"}}, layout: "2006/01/02", want: time.Date(1929, 4, 8, 0, 0, 0, 0, time.UTC), err: nil},
+		{params: Params{{key: testKey, val: "1929+04+08+12:24:59"}}, layout: "2006+01+02+15:04:05", want: time.Date(1929, 4, 8, 12, 24, 59, 0, time.UTC), err: nil},
 
 		// no param
 		{params: Params{}, layout: "2006-01-02", want: time.Time{}, err: ErrNoParam(testKey)},
 		// invalid value
-		{params: Params{{testKey, ""}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "", "2006", "", ""}},
-		{params: Params{{testKey, "foo bar"}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "foo bar", "2006", "foo bar", ""}},
-		{params: Params{{testKey, "08/15/1953"}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "08/15/1953", "2006", "08/15/1953", ""}},
+		{params: Params{{key: testKey, val: ""}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "", "2006", "", ""}},
+		{params: Params{{key: testKey, val: "foo bar"}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "foo bar", "2006", "foo bar", ""}},
+		{params: Params{{key: testKey, val: "08/15/1953"}}, layout: "2006-01-02", want: time.Time{}, err: &time.ParseError{"2006-01-02", "08/15/1953", "2006", "08/15/1953", ""}},
 	}
 	for i, tt := range tests {
 		got, err := tt.params.Time(testKey, tt.layout)
 		tt.check(t, i, got, err)
 	}
 }
+
+func TestParamError(t *testing.T) {
+	ps := Params{{key: "id", val: "abc"}}
+
+	_, err := ps.Int("id")
+	equals(t, 0, errors.Is(err, ErrParamMalformed), true)
+	equals(t, 1, errors.Is(err, ErrParamMissing), false)
+	equals(t, 2, errors.Is(err, strconv.ErrSyntax), true)
+
+	_, err = ps.Int("foo")
+	equals(t, 3, errors.Is(err, ErrParamMalformed), false)
+	equals(t, 4, errors.Is(err, ErrParamMissing), true)
+
+	var pe *ParamError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %T, want *ParamError", err)
+	}
+	equals(t, 5, *pe, ParamError{Key: "foo", Type: "int", Err: ErrNoParam("foo")})
+}
+
+func TestRouterSetParamErrorHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET", "/posts/{id}", func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		MustParam[int](c, "id")
+		MustParam[int](c, "page")
+	})
+	router.HandleFunc("GET", "/users/{id}", func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		if _, err := GetParams(c).Int("id"); err != nil {
+			HandleParamError(c, w, r, err)
+		}
+	})
+	router.HandleFunc("GET", "/foo", func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		HandleParamError(c, w, r, errors.New("foo"))
+	})
+
+	// without a ParamErrorHandler HandleParamError uses BadParam
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/users/abc", nil))
+	equals(t, 0, w.Code, http.StatusBadRequest)
+	equals(t, 1, w.Body.String(), "Invalid value for parameter \"id\"\n")
+
+	func() {
+		defer func() {
+			if _, ok := recover().(*ParamError); !ok {
+				t.Errorf("expected *ParamError panic without a ParamErrorHandler")
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), mustNewRequest("GET", "/posts/abc", nil))
+	}()
+
+	router.SetParamErrorHandler(BadParam)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/posts/abc", nil))
+	equals(t, 2, w.Code, http.StatusBadRequest)
+	equals(t, 3, w.Body.String(), "Invalid value for parameter \"id\"\n")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/posts/123", nil))
+	equals(t, 4, w.Code, http.StatusBadRequest)
+	equals(t, 5, w.Body.String(), "Missing parameter \"page\"\n")
+
+	router.SetParamErrorHandler(func(c context.Context, w http.ResponseWriter, r *http.Request, err *ParamError) {
+		http.Error(w, err.Key, http.StatusUnprocessableEntity)
+	})
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/posts/abc", nil))
+	equals(t, 6, w.Code, http.StatusUnprocessableEntity)
+	equals(t, 7, w.Body.String(), "id\n")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/users/abc", nil))
+	equals(t, 8, w.Code, http.StatusUnprocessableEntity)
+	equals(t, 9, w.Body.String(), "id\n")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/foo", nil))
+	equals(t, 10, w.Code, http.StatusInternalServerError)
+}
+
+func TestParamsCollection(t *testing.T) {
+	ps := NewParams("post_id", "123", "slug", "hello world")
+
+	equals(t, 0, ps.Len(), 2)
+	equals(t, 1, ps.Has("slug"), true)
+	equals(t, 2, ps.Has("foo"), false)
+	equals(t, 3, ps.Keys(), []string{"post_id", "slug"})
+	equals(t, 4, ps.Map(), map[string]string{"post_id": "123", "slug": "hello world"})
+
+	var keys []string
+	ps.Range(func(k, v string) bool {
+		keys = append(keys, k)
+		return false
+	})
+	equals(t, 5, keys, []string{"post_id"})
+
+	equals(t, 6, ps.With("slug", "bye"), NewParams("post_id", "123", "slug", "bye"))
+	equals(t, 7, ps.With("page", "2"), NewParams("post_id", "123", "slug", "hello world", "page", "2"))
+	equals(t, 8, ps.Without("post_id"), NewParams("slug", "hello world"))
+	equals(t, 9, ps, NewParams("post_id", "123", "slug", "hello world"))
+
+	c := ps.Clone()
+	c[0].val = "456"
+	equals(t, 10, ps.GetString("post_id"), "123")
+	equals(t, 11, Params(nil).Clone(), Params(nil))
+
+	equals(t, 12, fmt.Sprint(ps), "[post_id=123 slug=hello world]")
+	equals(t, 13, fmt.Sprintf("%q", ps), `[post_id="123" slug="hello world"]`)
+
+	b, err := json.Marshal(ps)
+	equals(t, 14, err, nil)
+	equals(t, 15, string(b), `{"post_id":"123","slug":"hello world"}`)
+	b, _ = json.Marshal(Params(nil))
+	equals(t, 16, string(b), `null`)
+	b, _ = json.Marshal(NewParams("id", "1", "slug", "foo", "id", "2", "id", "3"))
+	equals(t, 17, string(b), `{"id":["1","2","3"],"slug":"foo"}`)
+}
+
+func TestParamsSegments(t *testing.T) {
+	ps := NewParams("path", "go/http//router/", "empty", "", "ids", "1/22/333", "bad", "1/x")
+
+	equals(t, 0, ps.GetSegments("path"), []string{"go", "http", "router"})
+	equals(t, 1, ps.GetSegments("empty"), []string(nil))
+
+	_, err := ps.Segments("foo")
+	equals(t, 2, errors.Is(err, ErrParamMissing), true)
+
+	ids, err := Get[[]int](ps, "ids")
+	equals(t, 3, ids, []int{1, 22, 333})
+	equals(t, 4, err, nil)
+
+	_, err = Get[[]int](ps, "bad")
+	equals(t, 5, errors.Is(err, ErrParamMalformed), true)
+}
@@ -0,0 +1,190 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PartKind identifies the kind of a Part of a Pattern.
+type PartKind int
+
+const (
+	// StaticPart is a part that matches its text as is.
+	StaticPart PartKind = iota
+	// ParamPart is a param, e.g. "{id}", that matches the text
+	// up to its End separator or the next '/'.
+	ParamPart
+	// CatchAllPart is a catch-all, e.g. "*path", or a repeated param,
+	// e.g. "{path...}", that matches the rest of the text.
+	CatchAllPart
+)
+
+// String returns the name of the part kind.
+func (k PartKind) String() string {
+	switch k {
+	case StaticPart:
+		return "static"
+	case ParamPart:
+		return "param"
+	case CatchAllPart:
+		return "catch-all"
+	}
+	return fmt.Sprintf("PartKind(%d)", int(k))
+}
+
+// Part is a part of a Pattern, see ParsePattern.
+type Part struct {
+	Kind PartKind
+	// The text of a static part, or the name of a param or catch-all.
+	Value string
+	// The separators of a param, i.e. the byte that precedes the param if
+	// it follows a static part and the byte that follows the param, 0 if
+	// there is none. The Router requires the params in the same place of
+	// different patterns to have the same separators.
+	Start, End byte
+	// Repeated is set for the catch-alls written as repeated params, e.g. "{tags...}".
+	Repeated bool
+}
+
+// String returns the part as written in a pattern.
+func (p Part) String() string {
+	switch {
+	case p.Kind == ParamPart:
+		return "{" + p.Value + "}"
+	case p.Kind == CatchAllPart && p.Repeated:
+		return "{" + p.Value + "...}"
+	case p.Kind == CatchAllPart:
+		return "*" + p.Value
+	}
+	return p.Value
+}
+
+// Pattern is the structured representation of a pattern, see ParsePattern.
+type Pattern struct {
+	// The host part of the pattern, i.e. the part before the first '/',
+	// empty if the pattern begins with a '/'. The host patterns of the
+	// sub-routers, which have no path, are all host.
+	Host string
+	// The path part of the pattern, from the first '/' on.
+	Path string
+	// The parts of the pattern, host included, in order. A static part
+	// may span the end of the host and the beginning of the path.
+	Parts []Part
+
+	raw string
+}
+
+// String returns the pattern as given to ParsePattern.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Params returns the names of the pattern's params and catch-all, in order.
+func (p *Pattern) Params() []string {
+	var names []string
+	for _, pt := range p.Parts {
+		if pt.Kind != StaticPart {
+			names = append(names, pt.Value)
+		}
+	}
+	return names
+}
+
+// CatchAll returns the pattern's catch-all part, which is always the last
+// one, and reports whether the pattern has a catch-all.
+func (p *Pattern) CatchAll() (Part, bool) {
+	if n := len(p.Parts); n > 0 && p.Parts[n-1].Kind == CatchAllPart {
+		return p.Parts[n-1], true
+	}
+	return Part{}, false
+}
+
+// IsStatic reports whether the pattern has neither params nor a catch-all.
+func (p *Pattern) IsStatic() bool {
+	return len(p.Parts) <= 1 && (len(p.Parts) == 0 || p.Parts[0].Kind == StaticPart)
+}
+
+// Match matches the text, i.e. a host and path if the pattern has a host or a
+// path otherwise, against the pattern the way a Router would if the pattern were
+// its only one, and returns the values of the params. Unlike the Router it does
+// not unescape the values nor match the host's port separately.
+func (p *Pattern) Match(text string) (Params, bool) {
+	t := tree[struct{}]{sep: '/'}
+	if _, err := t.insert(p.raw, p.Parts, false, nil); err != nil {
+		return nil, false
+	}
+	lf, ps, _, _ := t.lookup(text, nil, nil)
+	return ps, lf != 0
+}
+
+// ParsePattern parses the pattern with the rules with which the Router registers
+// patterns and returns its structured representation. A param is written as its
+// name in curly braces, e.g. "{id}", a catch-all is written as an asterisk followed
+// by its name, e.g. "*path", or as a repeated param, e.g. "{path...}", and it must
+// end the pattern. Note that an asterisk followed by a param anywhere in the rest
+// of the pattern is static text, e.g. the pattern "/a*b/{c}" has the static part
+// "/a*b/" and the param "c", since a catch-all cannot be followed by a param.
+//
+// ParsePattern validates the pattern on its own, the Router may still reject it
+// if it conflicts with a previously registered pattern, e.g. if it has a param
+// with a different name in the same place.
+func ParsePattern(pattern string) (*Pattern, error) {
+	if pattern == "" {
+		return nil, errors.New("route: empty pattern")
+	}
+	parts, err := parsePattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("route: %s: %w", pattern, err)
+	}
+	p := &Pattern{raw: pattern, Host: pattern, Parts: parts}
+	if i := strings.IndexByte(pattern, '/'); i != -1 {
+		p.Host, p.Path = pattern[:i], pattern[i:]
+	}
+	return p, nil
+}
+
+// parsePattern splits the pattern into its parts, see ParsePattern. The
+// error, if any, is a *routeError.
+func parsePattern(pattern string) (parts []Part, err error) {
+	for pat := pattern; pat != ""; {
+		switch pat[0] {
+		case '*':
+			return append(parts, Part{Kind: CatchAllPart, Value: pat[1:]}), nil
+		case '{':
+			i := strings.IndexByte(pat, '}')
+			if i == -1 {
+				return nil, &routeError{typ: errUnclosedParam}
+			}
+			name := pat[1:i]
+			if strings.HasSuffix(name, "...") {
+				if len(pat) > (i + 1) {
+					return nil, &routeError{typ: errRepeatedParam, a: name}
+				}
+				return append(parts, Part{Kind: CatchAllPart, Value: strings.TrimSuffix(name, "..."), Repeated: true}), nil
+			}
+
+			pt := Part{Kind: ParamPart, Value: name}
+			if n := len(parts); n > 0 && parts[n-1].Kind == StaticPart {
+				prev := parts[n-1].Value
+				pt.Start = prev[len(prev)-1]
+			}
+			if len(pat) > (i + 1) {
+				pt.End = pat[i+1]
+			}
+			parts = append(parts, pt)
+			pat = pat[i+1:]
+		default:
+			i := strings.IndexByte(pat, '{')
+			if i == -1 {
+				i = strings.IndexByte(pat, '*')
+			}
+			if i == -1 {
+				i = len(pat)
+			}
+			parts = append(parts, Part{Kind: StaticPart, Value: pat[:i]})
+			pat = pat[i:]
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,229 @@
+package route
+
+import (
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		pattern string
+		host    string
+		path    string
+		parts   []Part
+		err     string
+	}{{
+		pattern: "/users",
+		path:    "/users",
+		parts:   []Part{{Kind: StaticPart, Value: "/users"}},
+	}, {
+		pattern: "/users/{id}/posts/{pid}",
+		path:    "/users/{id}/posts/{pid}",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/users/"},
+			{Kind: ParamPart, Value: "id", Start: '/', End: '/'},
+			{Kind: StaticPart, Value: "/posts/"},
+			{Kind: ParamPart, Value: "pid", Start: '/'},
+		},
+	}, {
+		pattern: "/files/{name}.{ext}",
+		path:    "/files/{name}.{ext}",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/files/"},
+			{Kind: ParamPart, Value: "name", Start: '/', End: '.'},
+			{Kind: StaticPart, Value: "."},
+			{Kind: ParamPart, Value: "ext", Start: '.'},
+		},
+	}, {
+		pattern: "/static/*file",
+		path:    "/static/*file",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/static/"},
+			{Kind: CatchAllPart, Value: "file"},
+		},
+	}, {
+		pattern: "/tags/{tags...}",
+		path:    "/tags/{tags...}",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/tags/"},
+			{Kind: CatchAllPart, Value: "tags", Repeated: true},
+		},
+	}, {
+		pattern: "{sub}.example.com/events/{id}",
+		host:    "{sub}.example.com",
+		path:    "/events/{id}",
+		parts: []Part{
+			{Kind: ParamPart, Value: "sub", End: '.'},
+			{Kind: StaticPart, Value: ".example.com/events/"},
+			{Kind: ParamPart, Value: "id", Start: '/'},
+		},
+	}, {
+		pattern: "{tenant}.example.com",
+		host:    "{tenant}.example.com",
+		parts: []Part{
+			{Kind: ParamPart, Value: "tenant", End: '.'},
+			{Kind: StaticPart, Value: ".example.com"},
+		},
+	}, {
+		// an asterisk followed by a param is static text
+		pattern: "/a*b/{c}",
+		path:    "/a*b/{c}",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/a*b/"},
+			{Kind: ParamPart, Value: "c", Start: '/'},
+		},
+	}, {
+		pattern: "/{a}*rest",
+		path:    "/{a}*rest",
+		parts: []Part{
+			{Kind: StaticPart, Value: "/"},
+			{Kind: ParamPart, Value: "a", Start: '/', End: '*'},
+			{Kind: CatchAllPart, Value: "rest"},
+		},
+	}, {
+		pattern: "",
+		err:     "route: empty pattern",
+	}, {
+		pattern: "/users/{id",
+		err:     "route: /users/{id: missing closing curly brace '}'",
+	}, {
+		pattern: "/tags/{tags...}/x",
+		err:     `route: /tags/{tags...}/x: The repeated param "tags..." must be the last segment of the pattern.`,
+	}}
+
+	for i, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("#%d: got error %v, want %s", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		equals(t, i, p.String(), tt.pattern)
+		equals(t, i, p.Host, tt.host)
+		equals(t, i, p.Path, tt.path)
+		equals(t, i, p.Parts, tt.parts)
+	}
+}
+
+// The parsed patterns must agree with the nodes that the tree creates
+// for them and with the number of params counted by countParams.
+func TestParsePattern_Tree(t *testing.T) {
+	//t.Skip()
+	patterns := []string{
+		"/", "/users/{id}/posts/{pid}", "/files/{name}.{ext}", "/static/*file",
+		"/tags/{tags...}", "{sub}.example.com/events/{id}", "/a*b/{c}", "/{a}*rest",
+		"/{a}{b}", "/x/{a*b}/y", "/x/{a{b}/y", "/x}/{a}", "/*",
+	}
+	for _, r := range parseRoutes {
+		patterns = append(patterns, r.path)
+	}
+	for _, r := range githubRoutes {
+		patterns = append(patterns, r.path)
+	}
+
+	for i, pattern := range patterns {
+		p, err := ParsePattern(pattern)
+		if err != nil {
+			t.Errorf("#%d: %s: unexpected error %v", i, pattern, err)
+			continue
+		}
+		tr := tree[struct{}]{sep: '/'}
+		if _, err := tr.insert(pattern, p.Parts, false, nil); err != nil {
+			t.Errorf("#%d: %s: unexpected insert error %v", i, pattern, err)
+			continue
+		}
+
+		var want []Part
+		for ni := int32(0); ni != -1; {
+			nd, next := &tr.nodes[ni], int32(-1)
+			for _, ci := range nd.children {
+				want = append(want, Part{Kind: StaticPart, Value: tr.nodes[ci].edge})
+				next = ci
+			}
+			if nd.param != 0 {
+				pn := &tr.params[nd.param]
+				want = append(want, Part{Kind: ParamPart, Value: pn.name, Start: pn.start, End: pn.end})
+				if next = -1; pn.child != 0 {
+					next = pn.child
+				}
+			}
+			if nd.catchall != 0 {
+				want = append(want, Part{Kind: CatchAllPart, Value: tr.catchalls[nd.catchall].name})
+			}
+			ni = next
+		}
+
+		got := make([]Part, len(p.Parts))
+		copy(got, p.Parts)
+		for j := range got {
+			got[j].Repeated = false
+		}
+		if !equalParts(got, want) {
+			t.Errorf("#%d: %s: got parts %v, want %v", i, pattern, got, want)
+		}
+		if n := int(countParams(p.Parts)); n != len(p.Params()) {
+			t.Errorf("#%d: %s: got %d params, countParams counts %d", i, pattern, len(p.Params()), n)
+		}
+	}
+}
+
+// equalParts compares the parts with the static parts joined, since
+// the tree may split the static text of a pattern into multiple edges.
+func equalParts(a, b []Part) bool {
+	join := func(parts []Part) (out []Part) {
+		for _, pt := range parts {
+			if n := len(out); n > 0 && pt.Kind == StaticPart && out[n-1].Kind == StaticPart {
+				out[n-1].Value += pt.Value
+				continue
+			}
+			out = append(out, pt)
+		}
+		return out
+	}
+	a, b = join(a), join(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPatternMatch(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		pattern string
+		text    string
+		params  Params
+		ok      bool
+	}{
+		{"/users/{id}", "/users/42", NewParams("id", "42"), true},
+		{"/users/{id}", "/users/42/x", nil, false},
+		{"/users/{id}", "/users/", nil, false},
+		{"/files/{name}.{ext}", "/files/app.min.js", NewParams("name", "app", "ext", "min.js"), true},
+		{"/files/{name}.{ext}", "/files/app.js", NewParams("name", "app", "ext", "js"), true},
+		{"/static/*file", "/static/css/app.css", NewParams("file", "css/app.css"), true},
+		{"/static/*file", "/static/", nil, false},
+		{"/tags/{tags...}", "/tags/go/http", NewParams("tags", "go/http"), true},
+		{"{sub}.example.com/x", "api.example.com/x", NewParams("sub", "api"), true},
+		{"/a/{b}/c", "/a//c", NewParams("b", ""), true},
+	}
+
+	for i, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		params, ok := p.Match(tt.text)
+		equals(t, i, ok, tt.ok)
+		equals(t, i, params, tt.params)
+	}
+}
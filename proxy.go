@@ -0,0 +1,117 @@
+package route
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies enables host and scheme resolution from forwarding headers for
+// requests whose remote address belongs to one of the given CIDR networks, e.g.
+// "10.0.0.0/8". For such requests the host used for matching host patterns is taken
+// from the RFC 7239 Forwarded header, or the X-Forwarded-Host header, and the scheme
+// from the Forwarded header, or the X-Forwarded-Proto header. If any of these headers
+// are present the Router's trailing slash redirects use absolute URLs.
+//
+// When a header holds a list of values, the last one, i.e. the one added by the proxy
+// closest to the Router, is used. Calling SetTrustedProxies with no arguments disables
+// the use of forwarding headers.
+func (r *Router) SetTrustedProxies(cidrs ...string) error {
+	var proxies []*net.IPNet
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("route.SetTrustedProxies: %v", err)
+		}
+		proxies = append(proxies, n)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.proxies = proxies
+	return nil
+}
+
+// The origin method returns the host and scheme of the request as seen by the
+// client. If the request does not come from a trusted proxy, or if the proxy did
+// not set any of the forwarding headers, origin returns req.Host and an empty scheme.
+func (r *Router) origin(req *http.Request) (host, scheme string) {
+	if len(r.proxies) == 0 || !r.trusted(req.RemoteAddr) {
+		return req.Host, ""
+	}
+
+	if vs := req.Header.Values("Forwarded"); len(vs) > 0 {
+		host, scheme = parseForwarded(lastValue(vs))
+	}
+	if host == "" {
+		host = lastValue(req.Header.Values("X-Forwarded-Host"))
+	}
+	if scheme == "" {
+		scheme = strings.ToLower(lastValue(req.Header.Values("X-Forwarded-Proto")))
+	}
+	if host == "" && scheme == "" {
+		return req.Host, ""
+	}
+
+	if host == "" {
+		host = req.Host
+	}
+	if scheme == "" {
+		if scheme = "http"; req.TLS != nil {
+			scheme = "https"
+		}
+	}
+	return host, scheme
+}
+
+// The trusted method reports whether the given remote address belongs to
+// one of the Router's trusted proxy networks.
+func (r *Router) trusted(addr string) bool {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range r.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastValue returns the last element of the comma separated list of values
+// in the last of the given header values.
+func lastValue(vs []string) string {
+	if len(vs) == 0 {
+		return ""
+	}
+	v := vs[len(vs)-1]
+	if i := strings.LastIndexByte(v, ','); i != -1 {
+		v = v[i+1:]
+	}
+	return strings.TrimSpace(v)
+}
+
+// parseForwarded returns the host and proto parameters of the given
+// element of a Forwarded header, as specified by RFC 7239.
+func parseForwarded(elem string) (host, proto string) {
+	for _, pair := range strings.Split(elem, ";") {
+		i := strings.IndexByte(pair, '=')
+		if i == -1 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(pair[:i]))
+		val := strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+		switch key {
+		case "host":
+			host = val
+		case "proto":
+			proto = strings.ToLower(val)
+		}
+	}
+	return host, proto
+}
@@ -0,0 +1,79 @@
+package route
+
+import (
+	"testing"
+)
+
+func TestRouterSetTrustedProxies(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/foo/bar", "handler_a"},
+		{"GET", "public.example.com/foo/bar", "handler_b"},
+		{"GET", "public.example.com/baz/", "handler_c"},
+	}.Router()
+
+	if err := router.SetTrustedProxies("10.0.0.0/8", "bad"); err == nil {
+		t.Fatal("SetTrustedProxies() should fail with an invalid CIDR")
+	}
+	if err := router.SetTrustedProxies("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote  string
+		header  map[string]string
+		path    string
+		handler string
+		code    int
+		loc     string
+	}{{
+		remote:  "10.1.2.3:4567",
+		header:  map[string]string{"X-Forwarded-Host": "public.example.com"},
+		path:    "/foo/bar",
+		handler: "handler_b", code: 200,
+	}, {
+		remote:  "192.168.0.1:4567",
+		header:  map[string]string{"X-Forwarded-Host": "public.example.com"},
+		path:    "/foo/bar",
+		handler: "handler_a", code: 200,
+	}, {
+		remote:  "10.1.2.3:4567",
+		header:  map[string]string{"Forwarded": `for=1.2.3.4;host="evil.com", for=5.6.7.8;host=public.example.com;proto=https`},
+		path:    "/foo/bar",
+		handler: "handler_b", code: 200,
+	}, {
+		remote: "10.1.2.3:4567",
+		header: map[string]string{"X-Forwarded-Host": "public.example.com", "X-Forwarded-Proto": "https"},
+		path:   "/baz",
+		code:   301, loc: "https://public.example.com/baz/",
+	}, {
+		remote: "10.1.2.3:4567",
+		header: map[string]string{"X-Forwarded-Host": "public.example.com"},
+		path:   "/baz",
+		code:   301, loc: "http://public.example.com/baz/",
+	}, {
+		remote: "10.1.2.3:4567",
+		header: map[string]string{"X-Forwarded-Host": "public.example.com", "X-Forwarded-Proto": "https"},
+		path:   "/baz/../qux",
+		code:   301, loc: "https://public.example.com/qux",
+	}, {
+		remote: "192.168.0.1:4567",
+		header: map[string]string{"X-Forwarded-Host": "public.example.com"},
+		path:   "/baz/../qux",
+		code:   301, loc: "/qux",
+	}}
+
+	for i, tt := range tests {
+		r := mustNewRequest("GET", "http://internal.svc"+tt.path, nil)
+		r.RemoteAddr = tt.remote
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+
+		w := newRecorder()
+		router.ServeHTTP(w, r)
+		equals(t, i, w.HeaderMap.Get("Handled-By"), tt.handler)
+		equals(t, i, w.Code, tt.code)
+		equals(t, i, w.HeaderMap.Get("Location"), tt.loc)
+	}
+}
@@ -4,9 +4,15 @@ package route
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,9 +23,36 @@ import (
 type Router struct {
 	mu    sync.RWMutex
 	hosts bool
-	root  *node
+	tree  tree[nodeHandler]
 
-	handle404 Handler
+	// If set, the Router's tree has been compacted and no more
+	// routes can be registered, see Freeze.
+	frozen bool
+
+	// The static field maps the fully static patterns, i.e. those without
+	// params and catch-alls, to their leaves in the tree, which allows
+	// requests for static routes to skip the tree lookup.
+	static map[string]int32
+
+	// If set, the Router matches requests on their escaped path.
+	rawPath bool
+
+	// The vhosts field holds the tree of host patterns registered with
+	// the Host method, each associated with its own sub-router.
+	vhosts *tree[*Router]
+	subs   map[string]*Router
+	host   string // the host pattern of a sub-router
+
+	// The proxies field holds the networks of the trusted proxies whose
+	// forwarding headers are used to determine a request's host and scheme.
+	proxies []*net.IPNet
+
+	handle404     Handler
+	handle405     Handler
+	handle400     ParamErrorHandler
+	handleInvalid ParamErrorHandler
+
+	mw []Middleware
 
 	ctxpool sync.Pool
 }
@@ -27,11 +60,15 @@ type Router struct {
 // NewRouter allocates and returns a new Router.
 func NewRouter() *Router {
 	r := &Router{}
-	r.root = &node{}
-	r.handle404 = HandlerFunc(NotFound)
+	r.tree.sep = '/'
+	r.tree.renameCatchAlls = true
+	r.handle404 = notFound{}
+	r.handle405 = HandlerFunc(MethodNotAllowed)
 
 	r.ctxpool.New = func() interface{} {
-		return &ctx{Params{}}
+		// preallocate enough room for the params of the patterns
+		// registered so far to avoid growing the Params in lookup
+		return &ctx{Params: make(Params, 0, r.paramCap())}
 	}
 	return r
 }
@@ -51,17 +88,38 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var (
-		c        = r.ctxpool.Get().(*ctx)
-		po       = c.Params
-		h, ps, _ = r.handler(req, po)
+		c            = r.ctxpool.Get().(*ctx)
+		po           = c.Params
+		host, scheme = r.origin(req)
+		h, ps, _, rt = r.route(req, host, scheme, po[:0], nil)
 	)
 
-	c.Params = ps
-	h.ServeHTTP(c, w, req)
+	c.Params, c.Route, c.ParamError = ps, rt, r.handle400
+	if r.handle400 != nil {
+		r.serveRecover(c, h, w, req)
+	} else {
+		h.ServeHTTP(c, w, req)
+	}
 
+	c.Route = nil
 	r.ctxpool.Put(c)
 }
 
+// The serveRecover method calls h and, if h panics with a *ParamError,
+// passes the error to the Router's ParamErrorHandler.
+func (r *Router) serveRecover(c context.Context, h Handler, w http.ResponseWriter, req *http.Request) {
+	defer func() {
+		if v := recover(); v != nil {
+			pe, ok := v.(*ParamError)
+			if !ok {
+				panic(v)
+			}
+			r.handle400(c, w, req, pe)
+		}
+	}()
+	h.ServeHTTP(c, w, req)
+}
+
 // Handler returns the Handler and Params to use for the given request, consulting
 // r.URL.Path and r.Method. If there is no Handler registered for the request's
 // path and method a not-found Handler will be returned. Handler is guaranteed to
@@ -69,7 +127,31 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 //
 // Handler also returns the registered pattern that matches the request.
 func (r *Router) Handler(req *http.Request) (h Handler, ps Params, pat string) {
-	return r.handler(req, Params{})
+	// like the pooled Params of ServeHTTP the Params are preallocated
+	// to avoid growing them during the lookup
+	return r.handler(req, make(Params, 0, r.paramCap()))
+}
+
+// The paramCap method returns the largest number of params of a request matched
+// by the Router, i.e. the params of its patterns, or those of the host patterns
+// of its sub-routers together with the params of the sub-routers' patterns.
+func (r *Router) paramCap() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := int(r.tree.maxParams)
+	if r.vhosts != nil {
+		var m int
+		for _, sub := range r.subs {
+			if c := sub.paramCap(); c > m {
+				m = c
+			}
+		}
+		if c := int(r.vhosts.maxParams) + m; c > n {
+			n = c
+		}
+	}
+	return n
 }
 
 type tsr int
@@ -80,37 +162,248 @@ const (
 	tsrWithoutSlash
 )
 
+// The handler method resolves the Handler for the given request appending any
+// matched parameters to po. The returned Handler is wrapped in the Router's middleware.
 func (r *Router) handler(req *http.Request, po Params) (h Handler, ps Params, pat string) {
-	var (
-		host  = req.Host
-		path  = req.URL.Path
-		redir tsr
-	)
-	if r.hosts {
-		h, ps, pat, redir = r.root.lookup(host+path, po)
+	host, scheme := r.origin(req)
+	h, ps, pat, _ = r.route(req, host, scheme, po, nil)
+	return h, ps, pat
+}
+
+// The route method resolves the Handler for the given request, appending any
+// matched parameters to po. The host and scheme are those of the request as seen
+// by the client, if the scheme is not empty trailing slash redirects use absolute
+// URLs. The returned Handler is wrapped in the Router's middleware, unless the
+// steps of the resolution are recorded in tr, in which case the Handler is not
+// meant to be called. The returned Route is the one whose Handler will handle
+// the request, or nil if there is none.
+func (r *Router) route(req *http.Request, host, scheme string, po Params, tr *trace) (h Handler, ps Params, pat string, rt *Route) {
+	if r.vhosts != nil {
+		if sub, hps := r.vhost(host, po, tr); sub != nil {
+			h, ps, pat, rt = sub.route(req, host, scheme, hps, tr)
+			if tr != nil {
+				return h, ps, pat, rt
+			}
+			return r.wrap(h), ps, pat, rt
+		}
 	}
-	if h == nil && redir == tsrNone {
-		h, ps, pat, redir = r.root.lookup(path, po)
+
+	path := req.URL.Path
+	if r.rawPath {
+		path = req.URL.EscapedPath()
 	}
-	if h == nil {
+	nh, ps, pat, redir, nf := r.resolve(host, path, po, tr)
+	if nh != nil {
+		if rt = nh.get(req.Method); rt != nil {
+			h = rt.handler
+			if rt.Rules != nil {
+				if err := rt.validate(ps[len(po):]); err != nil {
+					status := http.StatusNotFound
+					if rt = nil; r.handleInvalid != nil {
+						h, status = &invalidParam{err: err, h: r.handleInvalid}, http.StatusBadRequest
+					} else if nf != nil {
+						h = nf
+					} else {
+						h = r.handle404
+					}
+					if tr != nil {
+						tr.decide(status, "", "the param %s=%q fails the validation of the route: %v", err.Key, err.Value, err.Err)
+					}
+				}
+			}
+			if tr != nil && rt != nil {
+				tr.decide(http.StatusOK, "", "the route registered for %s %q handles the request", strings.Join(rt.Methods, ","), pat)
+			}
+		} else {
+			h = &methodNotAllowed{allow: nh.methods, h: r.handle405}
+			if tr != nil {
+				tr.decide(http.StatusMethodNotAllowed, "", "the pattern %q has no route for the method %s,"+
+					" the allowed methods are %s", pat, req.Method, nh.methodSet())
+			}
+		}
+	} else {
+		var prefix string
+		if scheme != "" {
+			prefix = scheme + "://" + host
+		}
 		if redir == tsrWithSlash {
-			h = RedirectHandler(path+"/", http.StatusMovedPermanently)
+			h = RedirectHandler(prefix+path+"/", http.StatusMovedPermanently)
+			if tr != nil {
+				tr.decide(http.StatusMovedPermanently, prefix+path+"/", "redirect to %q", prefix+path+"/")
+			}
 		} else if redir == tsrWithoutSlash {
-			h = RedirectHandler(path[:len(path)-1], http.StatusMovedPermanently)
+			h = RedirectHandler(prefix+path[:len(path)-1], http.StatusMovedPermanently)
+			if tr != nil {
+				tr.decide(http.StatusMovedPermanently, prefix+path[:len(path)-1], "redirect to %q", prefix+path[:len(path)-1])
+			}
+		} else if nf != nil {
+			h = nf
+			if tr != nil {
+				tr.decide(http.StatusNotFound, "", "no pattern matches, the not-found handler"+
+					" of the longest matching prefix handles the request")
+			}
 		} else {
-			h = r.handle404
+			if _, ok := r.handle404.(notFound); ok && prefix != "" {
+				h = notFound{prefix: prefix}
+			} else {
+				h = r.handle404
+			}
+			if tr != nil {
+				tr.decide(http.StatusNotFound, "", "no pattern matches, the Router's NotFound handler handles the request")
+			}
+		}
+		if len(po) > 0 {
+			// retain the host params of a sub-router
+			ps = po
 		}
 	}
 
-	return h, ps, pat
+	if tr != nil {
+		return h, ps, pat, rt
+	}
+	return r.wrap(h), ps, pat, rt
+}
+
+// The resolve method looks up the host and path in the Router's own tree, i.e.
+// without its sub-routers, and returns the node handler and the pattern that
+// match them, with the params appended to po. If no pattern matches, resolve
+// returns the trailing slash redirect recommendation and the not-found Handler
+// of the longest matching prefix, if any. The steps of the lookup are recorded
+// in tr unless it is nil.
+func (r *Router) resolve(host, path string, po Params, tr *trace) (nh *nodeHandler, ps Params, pat string, redir tsr, nf Handler) {
+	var lf, prefix int32
+	if r.hosts {
+		if tr != nil {
+			tr.add("look up %q since patterns that begin with a host are matched first", host+path)
+		}
+		var ok bool
+		if lf, ok = r.static[host+path]; ok {
+			ps = po
+		} else {
+			lf, ps, redir, prefix = r.tree.lookup(host+path, po, tr)
+		}
+	}
+	if lf == 0 && redir == tsrNone {
+		if tr != nil {
+			tr.add("look up the path %q", path)
+		}
+		var ok bool
+		if lf, ok = r.static[path]; ok {
+			ps = po
+		} else {
+			var pp int32
+			lf, ps, redir, pp = r.tree.lookup(path, po, tr)
+			if prefix == 0 {
+				prefix = pp
+			}
+		}
+	}
+	if prefix != 0 {
+		nf = r.tree.leaves[prefix].value.notFound
+	}
+	if lf == 0 {
+		return nil, ps, "", redir, nf
+	}
+	if r.rawPath {
+		unescapeParams(ps[len(po):])
+	}
+	l := &r.tree.leaves[lf]
+	return &l.value, ps, l.pattern, tsrNone, nf
+}
+
+// The vhost method returns the sub-router whose host pattern matches the
+// given host, together with the host's params appended to po. If no
+// sub-router matches the host, vhost returns nil. The steps of the
+// lookup are recorded in tr unless it is nil.
+func (r *Router) vhost(host string, po Params, tr *trace) (*Router, Params) {
+	if tr != nil {
+		tr.add("look up the host %q in the host patterns of the sub-routers", host)
+	}
+	lf, ps, _, _ := r.vhosts.lookup(host, po, tr)
+	if h := stripHostPort(host); lf == 0 && h != host {
+		if tr != nil {
+			tr.add("look up the host %q without its port", h)
+		}
+		lf, ps, _, _ = r.vhosts.lookup(h, po, tr)
+	}
+	if lf == 0 {
+		if tr != nil {
+			tr.add("no sub-router matches the host, continue with the Router's own routes")
+		}
+		return nil, nil
+	}
+	sub := r.vhosts.leaves[lf].value
+	if tr != nil {
+		tr.add("continue with the sub-router of the host pattern %q", sub.host)
+	}
+	return sub, ps
+}
+
+// The wrap method wraps h in the Router's middleware, the first
+// middleware in the list becoming the outermost one.
+func (r *Router) wrap(h Handler) Handler {
+	for i := len(r.mw) - 1; i >= 0; i-- {
+		h = r.mw[i](h)
+	}
+	return h
 }
 
 // Handle registers the handler for the given pattern and method. If a handler
-// already exists for that pattern and method, Handle panics.
-func (r *Router) Handle(method, pattern string, handler Handler) {
+// already exists for that pattern and method, Handle panics. The returned Route
+// describes the registration and can be used to name the route.
+func (r *Router) Handle(method, pattern string, handler Handler) *Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.frozen {
+		panic("route.Handle: router is frozen")
+	}
+	return r.handle(method, pattern, handler)
+}
+
+// Entry describes a route to be registered with HandleBulk.
+type Entry struct {
+	Method  string
+	Pattern string
+	Handler Handler
+}
+
+// HandleBulk registers the given entries the same way Handle does and returns
+// their Routes in the same order as the entries. HandleBulk is meant for loading
+// a large number of routes at once, e.g. a static route for each page of a site,
+// it acquires the Router's lock once and inserts the patterns in lexical order,
+// so that consecutive inserts share the most of their path through the tree.
+// If any of the entries cannot be registered HandleBulk panics.
+func (r *Router) HandleBulk(entries []Entry) []*Route {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.frozen {
+		panic("route.HandleBulk: router is frozen")
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		pi, pj := entries[order[i]].Pattern, entries[order[j]].Pattern
+		return pi < pj || (pi == pj && order[i] < order[j])
+	})
+	if r.static == nil {
+		r.static = make(map[string]int32, len(entries))
+	}
+
+	rts := make([]*Route, len(entries))
+	for _, i := range order {
+		e := entries[i]
+		rts[i] = r.handle(e.Method, e.Pattern, e.Handler)
+	}
+	return rts
+}
+
+// The handle method implements Handle, the caller must hold the Router's lock.
+func (r *Router) handle(method, pattern string, handler Handler) *Route {
 	if pattern == "" {
 		panic("route.Handle: empty pattern")
 	}
@@ -120,18 +413,116 @@ func (r *Router) Handle(method, pattern string, handler Handler) {
 	if handler == nil {
 		panic("route.Handle: nil handler")
 	}
+
+	rt := &Route{
+		Host:    r.host,
+		Pattern: pattern,
+		Methods: strings.Split(method, ","),
+		handler: handler,
+	}
+	if err := r.insert(rt); err != nil {
+		panic(fmt.Sprintf("route.Handle: %s %s: %v", method, pattern, err))
+	}
+	return rt
+}
+
+// The insert method inserts the Route into the Router's tree, and into the
+// map of static patterns if its pattern is static. The caller must hold the
+// Router's lock.
+func (r *Router) insert(rt *Route) error {
+	pattern := rt.Pattern
+	parts, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+	lf, err := r.tree.insert(pattern, parts, false, func(nh *nodeHandler) error {
+		return nh.set(rt)
+	})
+	if err != nil {
+		return err
+	}
 	if pattern[0] != '/' {
 		r.hosts = true
 	}
+	if len(parts) == 1 && parts[0].Kind == StaticPart {
+		if r.static == nil {
+			r.static = make(map[string]int32)
+		}
+		r.static[pattern] = lf
+	}
+	return nil
+}
 
-	if err := r.root.insert(method, pattern, handler); err != nil {
-		panic(fmt.Sprintf("route.Handle: %s %s: %v", method, pattern, err))
+// Host returns a sub-router that handles the requests whose host matches the
+// given pattern. The pattern may contain parameters, e.g. "{tenant}.example.com",
+// whose values are prepended to the Params seen by the sub-router's handlers.
+// The sub-router has its own NotFound and MethodNotAllowed handlers and its
+// own middleware, which is applied inside that of the parent Router.
+//
+// The sub-routers take precedence over the parent Router's patterns: a request
+// whose host matches the pattern of a sub-router is handled by the sub-router,
+// by its NotFound handler if none of its patterns match, even if a pattern
+// registered with the parent Router, e.g. "acme.example.com/x", would match it.
+// Router.Validate reports such patterns as host overlaps.
+//
+// Calling Host more than once with the same pattern returns the same sub-router.
+func (r *Router) Host(pattern string) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pattern == "" {
+		panic("route.Host: empty pattern")
+	}
+	if strings.IndexByte(pattern, '/') != -1 {
+		panic("route.Host: pattern must not contain a path")
+	}
+	if sub, ok := r.subs[pattern]; ok {
+		return sub
+	}
+	if r.frozen {
+		panic("route.Host: router is frozen")
+	}
+
+	if r.vhosts == nil {
+		r.vhosts = &tree[*Router]{sep: '/', renameCatchAlls: true}
+		r.subs = make(map[string]*Router)
+	}
+
+	sub := NewRouter()
+	sub.host = pattern
+	sub.rawPath = r.rawPath
+	parts, err := parsePattern(pattern)
+	if err == nil {
+		_, err = r.vhosts.insert(pattern, parts, false, func(v **Router) error {
+			*v = sub
+			return nil
+		})
+	}
+	if err != nil {
+		panic(fmt.Sprintf("route.Host: %s: %v", pattern, err))
+	}
+	r.subs[pattern] = sub
+	return sub
+}
+
+// Use appends the given middleware to the Router's middleware stack. The
+// middleware wraps every Handler the Router resolves, including the NotFound,
+// MethodNotAllowed and redirect handlers.
+func (r *Router) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range mw {
+		if m == nil {
+			panic("route.Use: nil middleware")
+		}
 	}
+	r.mw = append(r.mw, mw...)
 }
 
 // HandleFunc registers the handler function for the given pattern and method.
-func (r *Router) HandleFunc(method, pattern string, handler func(context.Context, http.ResponseWriter, *http.Request)) {
-	r.Handle(method, pattern, HandlerFunc(handler))
+func (r *Router) HandleFunc(method, pattern string, handler func(context.Context, http.ResponseWriter, *http.Request)) *Route {
+	return r.Handle(method, pattern, HandlerFunc(handler))
 }
 
 // SetNotFound installs the Router's NotFound handler to be used when there is no
@@ -142,6 +533,217 @@ func (r *Router) SetNotFound(h Handler) {
 	}
 }
 
+// SetRawPath sets whether the Router matches requests on their escaped path, as
+// returned by URL.EscapedPath, instead of the decoded URL.Path. When enabled, an
+// encoded slash "%2F" in a parameter's value does not end the parameter's segment,
+// the values are decoded after the path has been matched and the escaped values
+// are available through the Params' Raw method. Note that in this mode the static
+// parts of the patterns are matched against the escaped path and should therefore
+// be registered in their escaped form.
+//
+// The setting applies to the Router's sub-routers as well, whether they were
+// created with Host before or after the call.
+func (r *Router) SetRawPath(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rawPath = enabled
+	for _, sub := range r.subs {
+		sub.SetRawPath(enabled)
+	}
+}
+
+// SetNotFoundFor installs the NotFound handler to be used for the requests whose
+// URL path begins with the given prefix but has no matching pattern registered in
+// the Router. The prefix must end with a slash and may contain parameters, e.g.
+// "/api/" or "/users/{id}/", a trailing "*" is ignored, so "/api/*" is the same as
+// "/api/". If more than one prefix applies to a request's path, the handler of the
+// longest matching prefix is used. The prefix may also begin with a host.
+//
+// Registering a prefix never changes which pattern matches a request, a param of
+// the prefix therefore applies only if a registered pattern has the same param.
+func (r *Router) SetNotFoundFor(prefix string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.frozen {
+		panic("route.SetNotFoundFor: router is frozen")
+	}
+	if h == nil {
+		panic("route.SetNotFoundFor: nil handler")
+	}
+	if prefix != "" && prefix[0] != '/' {
+		r.hosts = true
+	}
+
+	prefix = strings.TrimSuffix(prefix, "*")
+	if err := r.insertNotFound(prefix, h); err != nil {
+		panic(fmt.Sprintf("route.SetNotFoundFor: %s: %v", prefix, err))
+	}
+}
+
+// The insertNotFound method sets h as the not-found Handler of the given prefix,
+// which must end with a '/'. The caller must hold the Router's lock.
+func (r *Router) insertNotFound(prefix string, h Handler) error {
+	if prefix == "" || prefix[len(prefix)-1] != '/' {
+		return &routeError{typ: errNotFoundPrefix}
+	}
+	parts, err := parsePattern(prefix)
+	if err != nil {
+		return err
+	}
+	_, err = r.tree.insert(prefix, parts, true, func(nh *nodeHandler) error {
+		nh.notFound = h
+		return nil
+	})
+	return err
+}
+
+// SetMethodNotAllowed installs the Router's MethodNotAllowed handler to be used
+// when the pattern matching a request's URL path has no handler registered for
+// the request's method. The Allow header is set before the handler is called.
+func (r *Router) SetMethodNotAllowed(h Handler) {
+	if h != nil {
+		r.handle405 = h
+	}
+}
+
+// Route describes a handler registered with a Router. A Route should not
+// be modified after the Router has started serving requests.
+type Route struct {
+	// The host pattern of the sub-router with which the route was
+	// registered, empty if the route was registered with a root Router.
+	Host string
+	// The pattern with which the route was registered.
+	Pattern string
+	// The name of the route, empty unless set with the Named method.
+	Name string
+	// The methods with which the route was registered, "*" meaning any method.
+	Methods []string
+	// Tags holds the labels attached to the route with the Tagged method.
+	Tags []string
+	// Meta holds the user metadata attached to the route with the WithMeta method.
+	Meta map[string]interface{}
+	// Rules holds the validation rules declared for the route's parameters
+	// with the WithRules method, keyed by the parameters' keys.
+	Rules map[string][]Rule
+
+	handler Handler
+}
+
+// Named sets the name of the route and returns the route.
+func (rt *Route) Named(name string) *Route {
+	rt.Name = name
+	return rt
+}
+
+// Tagged appends the given tags to the route and returns the route.
+func (rt *Route) Tagged(tags ...string) *Route {
+	rt.Tags = append(rt.Tags, tags...)
+	return rt
+}
+
+// HasTag reports whether the route is tagged with the given tag.
+func (rt *Route) HasTag(tag string) bool {
+	for _, t := range rt.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMeta associates the given value with the key in the route's metadata
+// and returns the route. Metadata can be used to describe the route, e.g. its
+// auth scopes, rate-limit class or documentation, and can be retrieved from
+// a request's Context using CurrentRoute, or from the Router using Walk.
+func (rt *Route) WithMeta(key string, val interface{}) *Route {
+	if rt.Meta == nil {
+		rt.Meta = make(map[string]interface{})
+	}
+	rt.Meta[key] = val
+	return rt
+}
+
+// Walk calls fn for every Route registered with the Router, including the Routes
+// of its sub-routers, which are walked in the lexical order of their host patterns
+// after the Routes of the Router itself. If fn returns an error Walk stops and
+// returns that error. The Routes are collected before fn is first called, so fn
+// may register new routes, which are not walked.
+func (r *Router) Walk(fn func(rt *Route) error) error {
+	for _, rt := range r.routes(nil) {
+		if err := fn(rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The routes method appends the Routes of the Router and its sub-routers
+// to list in the order in which Walk walks them.
+func (r *Router) routes(list []*Route) []*Route {
+	r.mu.RLock()
+	r.tree.walk(func(l *leaf[nodeHandler]) error {
+		list = append(list, l.value.list()...)
+		return nil
+	})
+	hosts := make([]string, 0, len(r.subs))
+	for h := range r.subs {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	subs := make([]*Router, len(hosts))
+	for i, h := range hosts {
+		subs[i] = r.subs[h]
+	}
+	r.mu.RUnlock()
+
+	for _, sub := range subs {
+		list = sub.routes(list)
+	}
+	return list
+}
+
+// SetParamErrorHandler installs the handler to be called when a Handler panics with
+// a *ParamError, e.g. by calling MustParam with a malformed parameter, or passes one
+// to HandleParamError. The BadParam function can be used to reply with an HTTP 400
+// bad request error. By default the Router does not recover from such panics, and
+// HandleParamError uses BadParam, passing nil restores the default.
+func (r *Router) SetParamErrorHandler(h ParamErrorHandler) {
+	r.handle400 = h
+}
+
+// HandleParamError passes err to the ParamErrorHandler of the Router that dispatched
+// the request whose Context is c, or to BadParam if the Router has none, if err is,
+// or wraps, a *ParamError, e.g. one returned by Param or by the typed methods of
+// Params. Otherwise it replies with an HTTP 500 internal server error. It is the
+// non-panicking counterpart of MustParam:
+//
+//	id, err := route.Param[int](c, "id")
+//	if err != nil {
+//		route.HandleParamError(c, w, r, err)
+//		return
+//	}
+func HandleParamError(c context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	var pe *ParamError
+	if !errors.As(err, &pe) {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h := BadParam
+	if c != nil {
+		if eh, ok := c.Value(paramErrorKey).(ParamErrorHandler); ok && eh != nil {
+			h = eh
+		}
+	}
+	h(c, w, r, pe)
+}
+
+// ParamErrorHandler handles requests whose parameters could not be parsed.
+type ParamErrorHandler func(c context.Context, w http.ResponseWriter, r *http.Request, err *ParamError)
+
+// Middleware is a function that wraps a Handler to add behaviour to it.
+type Middleware func(Handler) Handler
+
 // Handler is analoguous to go's standard net/http.Handler
 //
 // Objects implementing the Handler interface can be registered to serve a
@@ -163,17 +765,57 @@ func (f HandlerFunc) ServeHTTP(ctx context.Context, w http.ResponseWriter, r *ht
 
 // NotFound replies to the request with an HTTP 404 not found error. If the request
 // path is not in its canonical form the request will be redirected to the canonical path.
-func NotFound(_ context.Context, w http.ResponseWriter, r *http.Request) {
+func NotFound(c context.Context, w http.ResponseWriter, r *http.Request) {
+	notFound{}.ServeHTTP(c, w, r)
+}
+
+// The notFound handler is the Router's default NotFound handler. The prefix,
+// if not empty, is the scheme and host of the request as seen by the client,
+// with which the redirect to the canonical path uses an absolute URL.
+type notFound struct {
+	prefix string
+}
+
+func (nf notFound) ServeHTTP(_ context.Context, w http.ResponseWriter, r *http.Request) {
 	if r.Method != "CONNECT" {
 		clean := cleanPath(r.URL.Path)
-		if clean != r.URL.Path && clean != r.Referer() {
-			http.Redirect(w, r, clean, http.StatusMovedPermanently)
+		if clean != r.URL.Path && nf.prefix+clean != r.Referer() {
+			http.Redirect(w, r, nf.prefix+clean, http.StatusMovedPermanently)
 			return
 		}
 	}
 	http.NotFound(w, r)
 }
 
+// BadParam replies to the request with an HTTP 400 bad request error
+// describing the missing or malformed parameter.
+func BadParam(_ context.Context, w http.ResponseWriter, r *http.Request, err *ParamError) {
+	msg := "Invalid value for parameter " + strconv.Quote(err.Key)
+	if errors.Is(err, ErrParamMissing) {
+		msg = "Missing parameter " + strconv.Quote(err.Key)
+	}
+	http.Error(w, msg, http.StatusBadRequest)
+}
+
+// MethodNotAllowed replies to the request with an HTTP 405 method not allowed error.
+func MethodNotAllowed(_ context.Context, w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// The methodNotAllowed handler sets the Allow header and, unless the request
+// is an OPTIONS request, calls the Router's MethodNotAllowed handler.
+type methodNotAllowed struct {
+	allow string
+	h     Handler
+}
+
+func (mh *methodNotAllowed) ServeHTTP(c context.Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", mh.allow)
+	if r.Method != "OPTIONS" {
+		mh.h.ServeHTTP(c, w, r)
+	}
+}
+
 // Redirect to a fixed URL
 type redirectHandler struct {
 	url  string
@@ -200,9 +842,17 @@ func RedirectHandler(url string, code int) Handler {
 // to prevent collisions with keys defined in other packages.
 type ctxKey int
 
-// paramsKey is the key for route.Params values in Contexts. Clients should use
-// route.Context and route.GetParams instead of using this key directly.
-const paramsKey ctxKey = 0
+const (
+	// paramsKey is the key for route.Params values in Contexts. Clients should use
+	// route.Context and route.GetParams instead of using this key directly.
+	paramsKey ctxKey = iota
+	// routeKey is the key for *route.Route values in Contexts. Clients should
+	// use route.CurrentRoute instead of using this key directly.
+	routeKey
+	// paramErrorKey is the key for the Router's ParamErrorHandler in Contexts.
+	// Clients should use route.HandleParamError instead of using this key directly.
+	paramErrorKey
+)
 
 // Context returns a copy of parent which carries the Params value p.
 func Context(parent context.Context, p Params) context.Context {
@@ -220,6 +870,18 @@ func GetParams(c context.Context) Params {
 	return Params{}
 }
 
+// CurrentRoute returns the Route matched by the request whose Context is c. If no
+// Route was matched, e.g. when the request is being handled by the NotFound or
+// MethodNotAllowed handler, CurrentRoute returns nil.
+func CurrentRoute(c context.Context) *Route {
+	if c != nil {
+		if rt, ok := c.Value(routeKey).(*Route); ok {
+			return rt
+		}
+	}
+	return nil
+}
+
 // cleanPath is copied from net/http/server.go.
 // Return the canonical path for p, eliminating . and .. elements.
 func cleanPath(p string) string {
@@ -238,9 +900,37 @@ func cleanPath(p string) string {
 	return np
 }
 
+// unescapeParams decodes the escaped values of ps, retaining the escaped
+// value of each param whose decoded value differs.
+func unescapeParams(ps Params) {
+	for i := range ps {
+		if strings.IndexByte(ps[i].val, '%') == -1 {
+			continue
+		}
+		if val, err := url.PathUnescape(ps[i].val); err == nil {
+			ps[i].raw, ps[i].val = ps[i].val, val
+		}
+	}
+}
+
+// stripHostPort returns h without any trailing ":<port>".
+func stripHostPort(h string) string {
+	// If no port on host, return unchanged
+	if !strings.Contains(h, ":") {
+		return h
+	}
+	host, _, err := net.SplitHostPort(h)
+	if err != nil {
+		return h // on error, return unchanged
+	}
+	return host
+}
+
 // The ctx type implements the context.Context interface.
 type ctx struct {
-	Params Params
+	Params     Params
+	Route      *Route
+	ParamError ParamErrorHandler
 }
 
 func (c *ctx) Deadline() (time.Time, bool) {
@@ -256,8 +946,17 @@ func (c *ctx) Err() error {
 }
 
 func (c *ctx) Value(key interface{}) interface{} {
-	if key == paramsKey {
+	switch key {
+	case paramsKey:
 		return c.Params
+	case routeKey:
+		if c.Route != nil {
+			return c.Route
+		}
+	case paramErrorKey:
+		if c.ParamError != nil {
+			return c.ParamError
+		}
 	}
 	return nil
-}
\ No newline at end of file
+}
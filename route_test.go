@@ -2,10 +2,12 @@ package route
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -258,31 +260,31 @@ func TestRouterServeHTTP_Param(t *testing.T) {
 		}, {
 			method: "GET", path: "/foo/y/baz",
 			handler: "hanlder_b", code: 200,
-			params: Params{{"b", "y"}}, pattern: "/foo/{b}/baz",
+			params: Params{{key: "b", val: "y"}}, pattern: "/foo/{b}/baz",
 		}, {
 			method: "GET", path: "/foo/bar/z",
 			handler: "handler_c", code: 200,
-			params: Params{{"c", "z"}}, pattern: "/foo/bar/{c}",
+			params: Params{{key: "c", val: "z"}}, pattern: "/foo/bar/{c}",
 		}, {
 			method: "GET", path: "/foo/y/z",
 			handler: "handler_d", code: 200,
-			params: Params{{"b", "y"}, {"c", "z"}}, pattern: "/foo/{b}/{c}",
+			params: Params{{key: "b", val: "y"}, {key: "c", val: "z"}}, pattern: "/foo/{b}/{c}",
 		}, {
 			method: "GET", path: "/x/y/z",
 			handler: "handler_e", code: 200,
-			params: Params{{"a", "x"}, {"b", "y"}, {"c", "z"}}, pattern: "/{a}/{b}/{c}",
+			params: Params{{key: "a", val: "x"}, {key: "b", val: "y"}, {key: "c", val: "z"}}, pattern: "/{a}/{b}/{c}",
 		}, {
 			method: "GET", path: "/x/y/baz",
 			handler: "handler_f", code: 200,
-			params: Params{{"a", "x"}, {"b", "y"}}, pattern: "/{a}/{b}/baz",
+			params: Params{{key: "a", val: "x"}, {key: "b", val: "y"}}, pattern: "/{a}/{b}/baz",
 		}, {
 			method: "GET", path: "/x/bar/baz",
 			handler: "handler_g", code: 200,
-			params: Params{{"a", "x"}}, pattern: "/{a}/bar/baz",
+			params: Params{{key: "a", val: "x"}}, pattern: "/{a}/bar/baz",
 		}, {
 			method: "GET", path: "/x/bar/z",
 			handler: "handler_h", code: 200,
-			params: Params{{"a", "x"}, {"c", "z"}}, pattern: "/{a}/bar/{c}",
+			params: Params{{key: "a", val: "x"}, {key: "c", val: "z"}}, pattern: "/{a}/bar/{c}",
 		}, {
 			// NOTE(mkopriva): this case, as opposed to the previous one,
 			// checks that 'b' in the third segment matches the {c} param node
@@ -290,7 +292,7 @@ func TestRouterServeHTTP_Param(t *testing.T) {
 			// we matched using only the node.indices in lookup.
 			method: "GET", path: "/x/bar/b",
 			handler: "handler_h", code: 200,
-			params: Params{{"a", "x"}, {"c", "b"}}, pattern: "/{a}/bar/{c}",
+			params: Params{{key: "a", val: "x"}, {key: "c", val: "b"}}, pattern: "/{a}/bar/{c}",
 		},
 	}.Run(t, router)
 }
@@ -324,19 +326,43 @@ func TestRouterServeHTTP_MixedStaticParamsSegments(t *testing.T) {
 		}, {
 			method: "GET", path: "/abc",
 			handler: "handler_d", code: 200,
-			params: Params{{"x", "abc"}}, pattern: "/{x}",
+			params: Params{{key: "x", val: "abc"}}, pattern: "/{x}",
 		}, {
 			method: "GET", path: "/fox",
 			handler: "handler_d", code: 200,
-			params: Params{{"x", "fox"}}, pattern: "/{x}",
+			params: Params{{key: "x", val: "fox"}}, pattern: "/{x}",
 		}, {
 			method: "GET", path: "/fou/bat",
 			handler: "handler_e", code: 200,
-			params: Params{{"x", "bat"}}, pattern: "/fou/{x}",
+			params: Params{{key: "x", val: "bat"}}, pattern: "/fou/{x}",
 		}, {
 			method: "GET", path: "/fox/bag",
 			handler: "handler_h", code: 200,
-			params: Params{{"x", "fox"}, {"y", "bag"}}, pattern: "/{x}/{y}",
+			params: Params{{key: "x", val: "fox"}, {key: "y", val: "bag"}}, pattern: "/{x}/{y}",
+		},
+	}.Run(t, router)
+}
+
+func TestRouterServeHTTP_EdgeRepeatedInPath(t *testing.T) {
+	//t.Skip()
+	// the rest of the path equals the edge of the node that
+	// consumed its first part, e.g. "1" after the node "1"
+	router := routerSetup{
+		{"GET", "/x/{id}/a-1", "handler_a"},
+		{"GET", "/x/{id}/a-11", "handler_b"},
+		{"GET", "/x/{id}/a-12", "handler_c"},
+		{"GET", "/x/{id}/a-2", "handler_d"},
+	}.Router()
+
+	routerTests{
+		{
+			method: "GET", path: "/x/5/a-11",
+			handler: "handler_b", code: 200,
+			params: NewParams("id", "5"), pattern: "/x/{id}/a-11",
+		}, {
+			method: "GET", path: "/x/5/a-1",
+			handler: "handler_a", code: 200,
+			params: NewParams("id", "5"), pattern: "/x/{id}/a-1",
 		},
 	}.Run(t, router)
 }
@@ -365,19 +391,19 @@ func TestRouterServeHTTP_CatchAll(t *testing.T) {
 		}, {
 			method: "GET", path: "/foo/bar/x/y/z",
 			handler: "handler_b", code: 200,
-			params: Params{{"abc", "x/y/z"}}, pattern: "/foo/bar/*abc",
+			params: Params{{key: "abc", val: "x/y/z"}}, pattern: "/foo/bar/*abc",
 		}, {
 			method: "GET", path: "/foo/x/y/z",
 			handler: "handler_c", code: 200,
-			params: Params{{"abc", "x/y/z"}}, pattern: "/foo/*abc",
+			params: Params{{key: "abc", val: "x/y/z"}}, pattern: "/foo/*abc",
 		}, {
 			method: "GET", path: "/x/y/z",
 			handler: "handler_d", code: 200,
-			params: Params{{"abc", "x/y/z"}}, pattern: "/*abc",
+			params: Params{{key: "abc", val: "x/y/z"}}, pattern: "/*abc",
 		}, {
 			method: "GET", path: "/goo/car/x/y/z",
 			handler: "handler_e", code: 200,
-			params: Params{{"", "x/y/z"}}, pattern: "/goo/car/*",
+			params: Params{{key: "", val: "x/y/z"}}, pattern: "/goo/car/*",
 		}, {
 			method: "GET", path: "/goo/x/y/z",
 			handler: "", code: 404,
@@ -385,7 +411,7 @@ func TestRouterServeHTTP_CatchAll(t *testing.T) {
 		}, {
 			method: "GET", path: "/goo/xyz",
 			handler: "handler_f", code: 200,
-			params: Params{{"b", "xyz"}}, pattern: "/goo/{b}",
+			params: Params{{key: "b", val: "xyz"}}, pattern: "/goo/{b}",
 		},
 	}.Run(t, router)
 }
@@ -410,7 +436,7 @@ func TestRouterServeHTTP_Host(t *testing.T) {
 		}, {
 			method: "GET", path: "http://www.sample.co.uk/foo/bar",
 			handler: "handler_c", code: 200,
-			params: Params{{"sub", "www"}, {"tld", "co.uk"}}, pattern: "{sub}.sample.{tld}/foo/bar",
+			params: Params{{key: "sub", val: "www"}, {key: "tld", val: "co.uk"}}, pattern: "{sub}.sample.{tld}/foo/bar",
 		}, {
 			method: "GET", path: "http://www.example.com/foo/bar",
 			handler: "handler_a", code: 200,
@@ -651,6 +677,585 @@ func TestRouterHandleFunc(t *testing.T) {
 	r := mustNewRequest("GET", "/foo/bar-baz-qux", nil)
 
 	router.ServeHTTP(w, r)
-	equals(t, 0, w.Params(), Params{{"", "bar-baz-qux"}})
+	equals(t, 0, w.Params(), Params{{key: "", val: "bar-baz-qux"}})
 	equals(t, 0, w.HeaderMap.Get("Handled-By"), "handler_foo")
 }
+
+func TestRouterHandleBulk(t *testing.T) {
+	//t.Skip()
+	router := NewRouter()
+	rts := router.HandleBulk([]Entry{
+		{"GET", "/foo/{id}", strHandler("h_foo_id")},
+		{"GET", "/foo", strHandler("h_foo")},
+		{"POST", "/foo", strHandler("h_foo_post")},
+		{"GET", "/bar/*path", strHandler("h_bar")},
+	})
+	equals(t, 0, len(rts), 4)
+	equals(t, 1, rts[0].Pattern, "/foo/{id}")
+	equals(t, 2, rts[1].Pattern, "/foo")
+	equals(t, 3, rts[2].Methods, []string{"POST"})
+	equals(t, 4, rts[3].Pattern, "/bar/*path")
+
+	routerTests{
+		{"GET", "/foo", "h_foo", 200, Params{}, "/foo"},
+		{"POST", "/foo", "h_foo_post", 200, Params{}, "/foo"},
+		{"GET", "/foo/123", "h_foo_id", 200, NewParams("id", "123"), "/foo/{id}"},
+		{"GET", "/bar/a/b", "h_bar", 200, NewParams("path", "a/b"), "/bar/*path"},
+	}.Run(t, router)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("HandleBulk with a conflicting entry should panic")
+		}
+	}()
+	router.HandleBulk([]Entry{{"GET", "/foo", strHandler("h")}})
+}
+
+func TestRouterHost(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/foo/bar", "handler_a"},
+	}.Router()
+
+	tenant := router.Host("{tenant}.example.com")
+	tenant.Handle("GET", "/foo/bar", strHandler("handler_b"))
+	tenant.Handle("GET", "/foo/{id}", strHandler("handler_c"))
+	tenant.SetNotFound(HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Handled-By", "tenant not found")
+		w.WriteHeader(http.StatusNotFound)
+		recordContext(c, w)
+	}))
+	tenant.Use(func(h Handler) Handler {
+		return HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Tenant", GetParams(c).GetString("tenant"))
+			h.ServeHTTP(c, w, r)
+		})
+	})
+
+	if got := router.Host("{tenant}.example.com"); got != tenant {
+		t.Errorf("Host() with the same pattern should return the same sub-router")
+	}
+
+	routerTests{
+		{
+			method: "GET", path: "http://example.com/foo/bar",
+			handler: "handler_a", code: 200,
+			params: Params{}, pattern: "/foo/bar",
+		}, {
+			method: "GET", path: "http://acme.example.com/foo/bar",
+			handler: "handler_b", code: 200,
+			params: Params{{key: "tenant", val: "acme"}}, pattern: "/foo/bar",
+		}, {
+			method: "GET", path: "http://acme.example.com:8080/foo/123",
+			handler: "handler_c", code: 200,
+			params: Params{{key: "tenant", val: "acme"}, {key: "id", val: "123"}}, pattern: "/foo/{id}",
+		}, {
+			method: "GET", path: "http://acme.example.com/baz",
+			handler: "tenant not found", code: 404,
+			params: Params{{key: "tenant", val: "acme"}}, pattern: "",
+		}, {
+			method: "GET", path: "http://example.com/baz",
+			handler: "", code: 404,
+			params: Params{}, pattern: "",
+		}, {
+			method: "POST", path: "http://acme.example.com/foo/bar",
+			handler: "", code: 405,
+			params: Params{}, pattern: "/foo/bar",
+		},
+	}.Run(t, router)
+
+	w := newRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "http://acme.example.com/foo/bar", nil))
+	equals(t, 0, w.HeaderMap.Get("Tenant"), "acme")
+}
+
+func TestRouterHost_Precedence(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "acme.example.com/x", "handler_a"},
+		{"GET", "/y", "handler_b"},
+	}.Router()
+
+	routerTests{
+		{
+			method: "GET", path: "http://acme.example.com/x",
+			handler: "handler_a", code: 200,
+			params: Params{}, pattern: "acme.example.com/x",
+		},
+	}.Run(t, router)
+
+	// once registered the sub-router handles every request for its hosts,
+	// the parent Router's patterns for those hosts are shadowed
+	router.Host("{tenant}.example.com").Handle("GET", "/z", strHandler("handler_c"))
+
+	routerTests{
+		{
+			method: "GET", path: "http://acme.example.com/z",
+			handler: "handler_c", code: 200,
+			params: Params{{key: "tenant", val: "acme"}}, pattern: "/z",
+		}, {
+			method: "GET", path: "http://acme.example.com/x",
+			handler: "", code: 404,
+			params: Params{}, pattern: "",
+		}, {
+			method: "GET", path: "http://acme.example.com/y",
+			handler: "", code: 404,
+			params: Params{}, pattern: "",
+		}, {
+			method: "GET", path: "http://example.com/y",
+			handler: "handler_b", code: 200,
+			params: Params{}, pattern: "/y",
+		},
+	}.Run(t, router)
+}
+
+func TestRouterSetMethodNotAllowed(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET,PUT", "/foo", "handler"},
+	}.Router()
+	router.SetMethodNotAllowed(HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Handled-By", r.Method+" not allowed")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		recordContext(c, w)
+	}))
+
+	routerTests{
+		{
+			method: "POST", path: "/foo",
+			handler: "POST not allowed", code: 405,
+			params: Params{}, pattern: "/foo",
+		}, {
+			method: "OPTIONS", path: "/foo",
+			handler: "", code: 200,
+			params: Params{}, pattern: "/foo",
+		},
+	}.Run(t, router)
+
+	w := newRecorder()
+	router.ServeHTTP(w, mustNewRequest("DELETE", "/foo", nil))
+	equals(t, 0, w.HeaderMap.Get("Allow"), "GET,PUT")
+}
+
+func TestCurrentRoute(t *testing.T) {
+	//t.Skip()
+	var got *Route
+	router := NewRouter()
+	want := router.HandleFunc("GET,POST", "/foo/{id}", func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		got = CurrentRoute(c)
+	}).Named("foo")
+	router.Use(func(h Handler) Handler {
+		return HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+			if rt := CurrentRoute(c); rt == nil || rt.Pattern != "/foo/{id}" {
+				t.Errorf("middleware: got route %v, want pattern %q", rt, "/foo/{id}")
+			}
+			h.ServeHTTP(c, w, r)
+		})
+	})
+
+	router.ServeHTTP(newRecorder(), mustNewRequest("POST", "/foo/123", nil))
+	equals(t, 0, got, want)
+	equals(t, 1, got.Name, "foo")
+	equals(t, 2, got.Methods, []string{"GET", "POST"})
+
+	equals(t, 3, CurrentRoute(context.Background()), (*Route)(nil))
+	equals(t, 4, CurrentRoute(&ctx{}), (*Route)(nil))
+}
+
+func TestRouterWalk(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/foo", "h"},
+		{"POST,GET", "/foo/{id}", "h"},
+		{"DELETE", "/foo/{id}", "h"},
+		{"GET", "/static/*file", "h"},
+	}.Router()
+	router.Handle("GET", "/bar", strHandler("h")).
+		Tagged("admin").
+		WithMeta("scopes", []string{"bar:read"})
+	router.Host("api.example.com").Handle("*", "/baz", strHandler("h"))
+
+	type route struct {
+		host, pattern string
+		methods       []string
+	}
+	var got []route
+	err := router.Walk(func(rt *Route) error {
+		got = append(got, route{rt.Host, rt.Pattern, rt.Methods})
+		if rt.Pattern == "/bar" {
+			equals(t, 0, rt.HasTag("admin"), true)
+			equals(t, 0, rt.Meta["scopes"], []string{"bar:read"})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []route{
+		{"", "/foo", []string{"GET"}},
+		{"", "/foo/{id}", []string{"DELETE"}},
+		{"", "/foo/{id}", []string{"POST", "GET"}},
+		{"", "/static/*file", []string{"GET"}},
+		{"", "/bar", []string{"GET"}},
+		{"api.example.com", "/baz", []string{"*"}},
+	}
+	equals(t, 0, got, want)
+
+	stop := fmt.Errorf("stop")
+	var n int
+	err = router.Walk(func(rt *Route) error {
+		n++
+		return stop
+	})
+	equals(t, 1, err, stop)
+	equals(t, 2, n, 1)
+
+	// fn may register routes without deadlocking
+	n = 0
+	err = router.Walk(func(rt *Route) error {
+		n++
+		router.Handle("GET", fmt.Sprintf("/walked/%d", n), strHandler("h"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 3, n, len(want))
+}
+
+func TestRouterSetNotFoundFor(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/", "handler_a"},
+		{"GET", "/api/users", "handler_b"},
+		{"GET", "/api/users/{id}", "handler_c"},
+		{"GET", "/apix", "handler_d"},
+	}.Router()
+
+	notFound := func(name string) Handler {
+		return HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Handled-By", name)
+			w.WriteHeader(http.StatusNotFound)
+			recordContext(c, w)
+		})
+	}
+	router.SetNotFound(notFound("html"))
+	router.SetNotFoundFor("/api/*", notFound("json"))
+	router.SetNotFoundFor("/api/users/{id}/", notFound("user"))
+
+	routerTests{
+		{
+			method: "GET", path: "/api/users",
+			handler: "handler_b", code: 200,
+			params: Params{}, pattern: "/api/users",
+		}, {
+			method: "GET", path: "/foo",
+			handler: "html", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/apiy",
+			handler: "html", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/api/posts",
+			handler: "json", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/api/",
+			handler: "json", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/api/users/123/posts",
+			handler: "user", code: 404,
+			params: nil, pattern: "",
+		},
+	}.Run(t, router)
+
+	func() {
+		defer func() {
+			want := "route.SetNotFoundFor: /api: " + (&routeError{typ: errNotFoundPrefix}).Error()
+			if got := recover(); got != want {
+				t.Errorf("got %v, want %q", got, want)
+			}
+		}()
+		router.SetNotFoundFor("/api", notFound("bad"))
+	}()
+}
+
+func TestRouterSetNotFoundFor_KeepsRoutes(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/users/*rest", "handler_a"},
+		{"GET", "/{x}/foo/bar", "handler_b"},
+	}.Router()
+
+	// the prefixes must not change which routes match
+	router.SetNotFoundFor("/users/{id}/", strHandler("users_not_found"))
+	router.SetNotFoundFor("/api/{id}/", strHandler("api_not_found"))
+
+	routerTests{
+		{
+			method: "GET", path: "/users/abc/def",
+			handler: "handler_a", code: 200,
+			params: NewParams("rest", "abc/def"), pattern: "/users/*rest",
+		}, {
+			method: "GET", path: "/users/abc",
+			handler: "handler_a", code: 200,
+			params: NewParams("rest", "abc"), pattern: "/users/*rest",
+		}, {
+			method: "GET", path: "/api/foo/bar",
+			handler: "handler_b", code: 200,
+			params: NewParams("x", "api"), pattern: "/{x}/foo/bar",
+		},
+	}.Run(t, router)
+}
+
+func TestRouterSetNotFoundFor_DeepestPrefix(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/{x}", "handler_a"},
+	}.Router()
+
+	notFound := func(name string) Handler {
+		return HandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Handled-By", name)
+			w.WriteHeader(http.StatusNotFound)
+			recordContext(c, w)
+		})
+	}
+	router.SetNotFound(notFound("html"))
+	router.SetNotFoundFor("/api/*", notFound("json"))
+
+	routerTests{
+		{
+			method: "GET", path: "/api",
+			handler: "handler_a", code: 200,
+			params: NewParams("x", "api"), pattern: "/{x}",
+		}, {
+			method: "GET", path: "/api/foo",
+			handler: "json", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/api/foo/bar",
+			handler: "json", code: 404,
+			params: nil, pattern: "",
+		}, {
+			method: "GET", path: "/foo/bar",
+			handler: "html", code: 404,
+			params: nil, pattern: "",
+		},
+	}.Run(t, router)
+}
+
+func TestRouterServeHTTP_RepeatedParam(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/tags/{tags...}", "handler_a"},
+		{"GET", "/tags/popular", "handler_b"},
+	}.Router()
+
+	routerTests{
+		{
+			method: "GET", path: "/tags/go/http/router",
+			handler: "handler_a", code: 200,
+			params: Params{{key: "tags", val: "go/http/router"}}, pattern: "/tags/{tags...}",
+		}, {
+			method: "GET", path: "/tags/popular",
+			handler: "handler_b", code: 200,
+			params: Params{}, pattern: "/tags/popular",
+		},
+	}.Run(t, router)
+
+	func() {
+		defer func() {
+			want := "route.Handle: GET /tags/{tags...}/feed: " + (&routeError{typ: errRepeatedParam, a: "tags..."}).Error()
+			if got := recover(); got != want {
+				t.Errorf("got %v, want %q", got, want)
+			}
+		}()
+		router.Handle("GET", "/tags/{tags...}/feed", strHandler("h"))
+	}()
+}
+
+func TestRouterSetRawPath(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/files/{key}", "handler_a"},
+		{"GET", "/files/{key}/meta", "handler_b"},
+		{"GET", "/static/*path", "handler_c"},
+	}.Router()
+	router.Host("cdn.example.com").Handle("GET", "/files/{key}", strHandler("handler_d"))
+
+	routerTests{
+		{
+			method: "GET", path: "/files/a%2Fb/meta",
+			handler: "", code: 404,
+			params: Params{}, pattern: "",
+		},
+	}.Run(t, router)
+
+	router.SetRawPath(true)
+
+	routerTests{
+		{
+			method: "GET", path: "/files/a%2Fb",
+			handler: "handler_a", code: 200,
+			params: Params{{key: "key", val: "a/b", raw: "a%2Fb"}}, pattern: "/files/{key}",
+		}, {
+			method: "GET", path: "/files/a%2Fb/meta",
+			handler: "handler_b", code: 200,
+			params: Params{{key: "key", val: "a/b", raw: "a%2Fb"}}, pattern: "/files/{key}/meta",
+		}, {
+			method: "GET", path: "/files/plain",
+			handler: "handler_a", code: 200,
+			params: Params{{key: "key", val: "plain"}}, pattern: "/files/{key}",
+		}, {
+			method: "GET", path: "/static/a%20b/c",
+			handler: "handler_c", code: 200,
+			params: Params{{key: "path", val: "a b/c", raw: "a%20b/c"}}, pattern: "/static/*path",
+		}, {
+			method: "GET", path: "http://cdn.example.com/files/a%2Fb",
+			handler: "handler_d", code: 200,
+			params: Params{{key: "key", val: "a/b", raw: "a%2Fb"}}, pattern: "/files/{key}",
+		},
+	}.Run(t, router)
+
+	w := newRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/files/a%2Fb", nil))
+	equals(t, 0, w.Params().GetString("key"), "a/b")
+	equals(t, 1, w.Params().GetRaw("key"), "a%2Fb")
+}
+
+func TestRouterServeHTTP_ZeroAlloc(t *testing.T) {
+	//t.Skip()
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	for i, routes := range [][]benchRoute{staticRoutes, githubRoutes, parseRoutes, githubRoutes} {
+		router := benchRouter(routes)
+		if i == 3 {
+			router.Freeze()
+		}
+		reqs := benchRequests(routes)
+		w := &nopWriter{h: http.Header{}}
+
+		// warm up the context pool
+		for _, r := range reqs {
+			if _, _, pat := router.Handler(r); pat == "" {
+				t.Errorf("%s %s: no pattern matched", r.Method, r.URL.Path)
+			}
+			router.ServeHTTP(w, r)
+		}
+		allocs := testing.AllocsPerRun(10, func() {
+			for _, r := range reqs {
+				router.ServeHTTP(w, r)
+			}
+		})
+		if allocs > 0 {
+			t.Errorf("ServeHTTP of %d routes: got %v allocs, want 0", len(routes), allocs)
+		}
+	}
+}
+
+func TestRouterParamCap(t *testing.T) {
+	//t.Skip()
+	router := routerSetup{
+		{"GET", "/{a}/{b}", "h"},
+	}.Router()
+	router.Host("{x}.{y}.example.com").Handle("GET", "/{c}/{d}/{e}", strHandler("h"))
+	equals(t, 0, router.paramCap(), 5)
+
+	// the pooled Params are large enough for the requests of the sub-routers
+	c := router.ctxpool.Get().(*ctx)
+	equals(t, 1, cap(c.Params), 5)
+
+	_, ps, _ := router.Handler(mustNewRequest("GET", "http://a.b.example.com/c/d/e", nil))
+	equals(t, 2, len(ps), 5)
+	equals(t, 3, cap(ps), 5)
+}
+
+func TestRouterStaticFastPath(t *testing.T) {
+	//t.Skip()
+	routes := append(append([]benchRoute{}, githubRoutes...), staticRoutes...)
+	routes = append(routes, benchRoute{"GET", "example.com/user"}, benchRoute{"GET", "{sub}.example.com/events"})
+
+	fast := benchRouter(routes)
+	slow := benchRouter(routes)
+	slow.static = nil
+	compareRouters(t, fast, slow, routes)
+}
+
+// compareRouters checks that the routers a and b resolve the same handlers,
+// params and patterns for requests made to the given routes and variations thereof.
+func compareRouters(t *testing.T, a, b *Router, routes []benchRoute) {
+	var sameHandler func(a, b Handler) bool
+	sameHandler = func(a, b Handler) bool {
+		if ma, ok := a.(*methodNotAllowed); ok {
+			mb, ok := b.(*methodNotAllowed)
+			return ok && ma.allow == mb.allow && sameHandler(ma.h, mb.h)
+		}
+		if fa, ok := a.(HandlerFunc); ok {
+			fb, ok := b.(HandlerFunc)
+			return ok && reflect.ValueOf(fa).Pointer() == reflect.ValueOf(fb).Pointer()
+		}
+		return reflect.DeepEqual(a, b)
+	}
+
+	for _, rt := range routes {
+		p := benchPath(rt.path[strings.IndexByte(rt.path, '/'):])
+		for _, path := range []string{p, p + "/", strings.TrimSuffix(p, "/"), p + "x", p + "/x"} {
+			for _, host := range []string{"example.com", "api.example.com", "other.com"} {
+				r := mustNewRequest(rt.method, "http://"+host+path, nil)
+				h1, ps1, pat1 := a.Handler(r)
+				h2, ps2, pat2 := b.Handler(r)
+				if !sameHandler(h1, h2) || !reflect.DeepEqual(ps1, ps2) || pat1 != pat2 {
+					t.Errorf("%s %s%s: got (%v, %v, %q), want (%v, %v, %q)",
+						rt.method, host, path, h1, ps1, pat1, h2, ps2, pat2)
+				}
+			}
+		}
+	}
+}
+
+func TestRouterFreeze(t *testing.T) {
+	//t.Skip()
+	routes := append(append([]benchRoute{}, githubRoutes...), staticRoutes...)
+	routes = append(routes, parseRoutes...)
+	routes = append(routes,
+		benchRoute{"GET", "example.com/user"},
+		benchRoute{"GET", "{sub}.example.com/events"},
+		benchRoute{"GET", "/files/{name}.{ext}"},
+		benchRoute{"GET", "/tags/{tags...}"},
+	)
+
+	frozen := benchRouter(routes)
+	frozen.SetNotFoundFor("/repos/", HandlerFunc(NotFound))
+	frozen.Freeze()
+	frozen.Freeze()
+	equals(t, 0, frozen.Frozen(), true)
+
+	mutable := benchRouter(routes)
+	mutable.SetNotFoundFor("/repos/", HandlerFunc(NotFound))
+	compareRouters(t, frozen, mutable, routes)
+
+	var n1, n2 int
+	frozen.Walk(func(*Route) error { n1++; return nil })
+	mutable.Walk(func(*Route) error { n2++; return nil })
+	equals(t, 1, n1, n2)
+
+	for _, f := range []func(){
+		func() { frozen.Handle("GET", "/new", nopHandler{}) },
+		func() { frozen.SetNotFoundFor("/new/", nopHandler{}) },
+		func() { frozen.Host("new.example.com") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registration with a frozen router should panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
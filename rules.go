@@ -0,0 +1,151 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule is a validation rule for the value of a parameter. Rules are declared for
+// a Route with the Route's WithRules method and are checked by the Router before
+// the Route's Handler is called.
+type Rule struct {
+	// The name of the rule, e.g. "min_len", "range" or "one_of".
+	Name string
+	// A human readable description of the values allowed by the
+	// rule, e.g. "length >= 3", suitable for documentation.
+	Desc string
+
+	check func(val string) bool
+}
+
+// Check reports whether the given value satisfies the rule.
+func (r Rule) Check(val string) bool {
+	return r.check(val)
+}
+
+// RuleError is the underlying error of the ParamError reported
+// for a parameter whose value does not satisfy a Rule.
+type RuleError struct {
+	Rule Rule
+}
+
+// Error implements the error interface.
+func (e *RuleError) Error() string {
+	return "value does not satisfy " + e.Rule.Name + " (" + e.Rule.Desc + ")"
+}
+
+// MinLen returns a Rule that requires the value to have at least n characters.
+func MinLen(n int) Rule {
+	return Rule{"min_len", "length >= " + strconv.Itoa(n), func(v string) bool {
+		return utf8.RuneCountInString(v) >= n
+	}}
+}
+
+// MaxLen returns a Rule that requires the value to have at most n characters.
+func MaxLen(n int) Rule {
+	return Rule{"max_len", "length <= " + strconv.Itoa(n), func(v string) bool {
+		return utf8.RuneCountInString(v) <= n
+	}}
+}
+
+// Range returns a Rule that requires the value to be an integer between
+// lo and hi, inclusive.
+func Range(lo, hi int64) Rule {
+	desc := fmt.Sprintf("%d <= value <= %d", lo, hi)
+	return Rule{"range", desc, func(v string) bool {
+		i, err := strconv.ParseInt(v, 10, 64)
+		return err == nil && i >= lo && i <= hi
+	}}
+}
+
+// OneOf returns a Rule that requires the value to be one of the given values.
+func OneOf(vals ...string) Rule {
+	desc := "one of " + strings.Join(vals, ", ")
+	return Rule{"one_of", desc, func(v string) bool {
+		for _, x := range vals {
+			if x == v {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// Predicate returns a Rule with the given name and description that
+// requires the value to satisfy the given function.
+func Predicate(name, desc string, fn func(val string) bool) Rule {
+	if fn == nil {
+		panic("route.Predicate: nil function")
+	}
+	return Rule{name, desc, fn}
+}
+
+// WithRules declares the rules that the value of the route's parameter with the
+// given key must satisfy and returns the route. If a request's parameter value
+// does not satisfy any of the rules, the Router calls its InvalidParam handler
+// instead of the route's Handler. WithRules panics if the route's pattern has no
+// parameter with the given key.
+func (rt *Route) WithRules(key string, rules ...Rule) *Route {
+	if !hasParam(rt.Pattern, key) {
+		panic(fmt.Sprintf("route.WithRules: %s: no param %q", rt.Pattern, key))
+	}
+	for _, r := range rules {
+		if r.check == nil {
+			panic(fmt.Sprintf("route.WithRules: %s: invalid rule for %q", rt.Pattern, key))
+		}
+	}
+	if rt.Rules == nil {
+		rt.Rules = make(map[string][]Rule)
+	}
+	rt.Rules[key] = append(rt.Rules[key], rules...)
+	return rt
+}
+
+// validate checks the values of ps against the rules of the route and
+// returns the error of the first value that does not satisfy a rule.
+func (rt *Route) validate(ps Params) *ParamError {
+	for _, p := range ps {
+		for _, r := range rt.Rules[p.key] {
+			if !r.check(p.val) {
+				return &ParamError{Key: p.key, Value: p.val, Type: "string", Err: &RuleError{r}}
+			}
+		}
+	}
+	return nil
+}
+
+// hasParam reports whether the pattern has a parameter with the given key.
+func hasParam(pattern, key string) bool {
+	parts, _ := parsePattern(pattern)
+	for _, pt := range parts {
+		if pt.Kind != StaticPart && pt.Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetInvalidParam installs the handler to be called when a request's parameter
+// value does not satisfy the rules declared for the matched Route. By default
+// the Router treats such a request as not matching any pattern and replies with
+// its NotFound handler, or with the handler of the longest matching prefix set
+// with SetNotFoundFor. The BadParam function can be used to reply with an HTTP
+// 400 bad request error instead. Passing nil restores the default.
+func (r *Router) SetInvalidParam(h ParamErrorHandler) {
+	r.handleInvalid = h
+}
+
+// The invalidParam handler passes the error of an invalid parameter
+// to the Router's InvalidParam handler.
+type invalidParam struct {
+	err *ParamError
+	h   ParamErrorHandler
+}
+
+func (ih *invalidParam) ServeHTTP(c context.Context, w http.ResponseWriter, r *http.Request) {
+	ih.h(c, w, r, ih.err)
+}
@@ -0,0 +1,101 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouteWithRules(t *testing.T) {
+	//t.Skip()
+	router := NewRouter()
+	router.Handle("GET", "/posts/{id}/{status}", strHandler("handler_a")).
+		WithRules("id", Range(1, 1000)).
+		WithRules("status", OneOf("draft", "published"))
+	router.Handle("GET", "/users/{name}", strHandler("handler_b")).
+		WithRules("name", MinLen(3), MaxLen(5), Predicate("lower", "lowercase", func(v string) bool {
+			return v == strings.ToLower(v)
+		}))
+
+	routerTests{
+		{
+			method: "GET", path: "/posts/12/draft",
+			handler: "handler_a", code: 200,
+			params: NewParams("id", "12", "status", "draft"), pattern: "/posts/{id}/{status}",
+		}, {
+			method: "GET", path: "/posts/0/draft",
+			handler: "", code: 404,
+			params: Params{}, pattern: "/posts/{id}/{status}",
+		}, {
+			method: "GET", path: "/posts/12/deleted",
+			handler: "", code: 404,
+			params: Params{}, pattern: "/posts/{id}/{status}",
+		}, {
+			method: "GET", path: "/users/bob",
+			handler: "handler_b", code: 200,
+			params: NewParams("name", "bob"), pattern: "/users/{name}",
+		}, {
+			method: "GET", path: "/users/Bob",
+			handler: "", code: 404,
+			params: Params{}, pattern: "/users/{name}",
+		}, {
+			method: "GET", path: "/users/bo",
+			handler: "", code: 404,
+			params: Params{}, pattern: "/users/{name}",
+		},
+	}.Run(t, router)
+
+	router.SetNotFoundFor("/users/", strHandler("users_not_found"))
+	w := newRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/users/Bob", nil))
+	equals(t, 0, w.HeaderMap.Get("Handled-By"), "users_not_found")
+
+	router.SetInvalidParam(BadParam)
+	w = newRecorder()
+	router.ServeHTTP(w, mustNewRequest("GET", "/users/bobbybob", nil))
+	equals(t, 1, w.Code, http.StatusBadRequest)
+	equals(t, 2, w.Body.String(), "Invalid value for parameter \"name\"\n")
+
+	var rules []string
+	router.Walk(func(rt *Route) error {
+		for _, r := range rt.Rules["name"] {
+			rules = append(rules, r.Name+": "+r.Desc)
+		}
+		return nil
+	})
+	equals(t, 3, rules, []string{"min_len: length >= 3", "max_len: length <= 5", "lower: lowercase"})
+
+	func() {
+		defer func() {
+			if got, want := recover(), `route.WithRules: /users/{name}: no param "id"`; got != want {
+				t.Errorf("got %v, want %q", got, want)
+			}
+		}()
+		router.Handle("POST", "/users/{name}", strHandler("h")).WithRules("id", MinLen(1))
+	}()
+}
+
+func TestHasParam(t *testing.T) {
+	//t.Skip()
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"/users/{id}", "id", true},
+		{"/users/{id}", "i", false},
+		{"/users/{id}/x{idx}", "idx", true},
+		{"/tags/{tags...}", "tags", true},
+		{"/static/*path", "path", true},
+		{"/static/*path", "ath", false},
+		{"/a*b/{c}", "b", false},
+		{"/a*b/{c}", "c", true},
+		{"{sub}.example.com/x", "sub", true},
+		{"/a/{b}x", "b}x", false},
+		{"/a/{b}/{c}", "b}/{c", false},
+		{"/tags/{tags...}", "tags...", false},
+	}
+	for i, tt := range tests {
+		equals(t, i, hasParam(tt.pattern, tt.key), tt.want)
+	}
+}
@@ -0,0 +1,143 @@
+package route
+
+import (
+	"unsafe"
+)
+
+// Stats holds statistics about the routing trees of a Router, see Router.Stats.
+type Stats struct {
+	// The total number of nodes, i.e. the sum of StaticNodes,
+	// ParamNodes and CatchAllNodes.
+	Nodes int
+	// The number of nodes that match a static part of a path.
+	StaticNodes int
+	// The number of nodes that match a parameter.
+	ParamNodes int
+	// The number of nodes that match a catch-all.
+	CatchAllNodes int
+	// The length of the longest path from a tree's root to one of its nodes.
+	Depth int
+	// The number of distinct patterns with at least one Route.
+	Patterns int
+	// The number of Routes, as visited by Walk.
+	Routes int
+	// The number of Routes that match on the request's host, i.e. the Routes
+	// whose pattern begins with a host and the Routes of the sub-routers.
+	HostRoutes int
+	// The sum of the number of params of every pattern.
+	TotalParams int
+	// The number of params of the pattern with the most params.
+	MaxParams int
+	// An estimate of the memory, in bytes, used by the trees, not including
+	// the Routes, their handlers and the patterns' strings.
+	Memory int
+}
+
+// Stats returns statistics about the Router's routing tree, and the trees of its
+// sub-routers, which can be used to diagnose pathological route sets, e.g. a set
+// whose tree is much deeper, or has many more nodes, than expected.
+func (r *Router) Stats() Stats {
+	var s Stats
+	r.stats(&s)
+	return s
+}
+
+// The stats method adds the statistics of the Router and its sub-routers to s.
+func (r *Router) stats(s *Stats) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tree.nodes) > 0 {
+		s.addTree(&r.tree, 0, 0, 0, r.host != "")
+	}
+	s.Memory += r.tree.memory()
+	for i := range r.tree.leaves {
+		s.Memory += r.tree.leaves[i].value.memory()
+	}
+	for _, sub := range r.subs {
+		sub.stats(s)
+	}
+}
+
+// addTree adds the statistics of the subtree rooted at the node ni of the tree t,
+// which is at the given depth below the given number of params, to s. If host is
+// true all of its Routes are host routes.
+func (s *Stats) addTree(t *tree[nodeHandler], ni int32, depth, params int, host bool) {
+	nd := &t.nodes[ni]
+	s.Nodes++
+	s.StaticNodes++
+	if depth > s.Depth {
+		s.Depth = depth
+	}
+	s.addLeaf(t, nd.leaf, params, host)
+
+	for _, ci := range nd.children {
+		s.addTree(t, ci, depth+1, params, host)
+	}
+	if nd.param != 0 {
+		p := &t.params[nd.param]
+		s.Nodes++
+		s.ParamNodes++
+		if depth+1 > s.Depth {
+			s.Depth = depth + 1
+		}
+		s.addLeaf(t, p.leaf, params+1, host)
+		if p.child != 0 {
+			s.addTree(t, p.child, depth+2, params+1, host)
+		}
+	}
+	if nd.catchall != 0 {
+		s.Nodes++
+		s.CatchAllNodes++
+		if depth+1 > s.Depth {
+			s.Depth = depth + 1
+		}
+		s.addLeaf(t, t.catchalls[nd.catchall].leaf, params+1, host)
+	}
+}
+
+// addLeaf adds the statistics of the pattern and Routes of the leaf lf of the tree t,
+// whose pattern has the given number of params, to s.
+func (s *Stats) addLeaf(t *tree[nodeHandler], lf int32, params int, host bool) {
+	if lf == 0 {
+		return
+	}
+	l := &t.leaves[lf]
+	n := len(l.value.list())
+	s.Patterns++
+	s.Routes += n
+	if host || l.pattern[0] != '/' {
+		s.HostRoutes += n
+	}
+
+	s.TotalParams += params
+	if params > s.MaxParams {
+		s.MaxParams = params
+	}
+}
+
+// memory returns an estimate of the memory used by the tree, not including
+// the memory referenced by the values of its leaves. The edges are not
+// included since they share memory with the patterns.
+func (t *tree[L]) memory() int {
+	m := int(unsafe.Sizeof(*t)) +
+		cap(t.nodes)*int(unsafe.Sizeof(node{})) +
+		cap(t.params)*int(unsafe.Sizeof(paramNode{})) +
+		cap(t.catchalls)*int(unsafe.Sizeof(catchallNode{})) +
+		cap(t.leaves)*int(unsafe.Sizeof(leaf[L]{}))
+	for i := range t.nodes {
+		m += len(t.nodes[i].indices) + cap(t.nodes[i].children)*int(unsafe.Sizeof(int32(0)))
+	}
+	return m
+}
+
+// memory returns an estimate of the memory referenced by the node handler,
+// not including the node handler itself which is stored in a leaf.
+func (nh *nodeHandler) memory() int {
+	m := len(nh.methods)
+	if nh.ext != nil {
+		// roughly the size of an entry plus the map's own overhead
+		m += 48 + len(nh.ext)*int(unsafe.Sizeof("")+unsafe.Sizeof(nh))
+	}
+	return m
+}
@@ -0,0 +1,50 @@
+package route
+
+import (
+	"testing"
+)
+
+func statsRouter() *Router {
+	router := routerSetup{
+		{"GET", "/", "h"},
+		{"GET,POST", "/users", "h"},
+		{"GET", "/users/{id}", "h"},
+		{"GET", "/users/{id}/posts/{post}", "h"},
+		{"GET", "/static/*file", "h"},
+		{"GET", "{sub}.example.com/events", "h"},
+	}.Router()
+	router.Host("api.example.com").Handle("*", "/v1/ping", strHandler("h"))
+	return router
+}
+
+func TestRouterStats(t *testing.T) {
+	//t.Skip()
+	router := statsRouter()
+	want := Stats{
+		Nodes:         15,
+		StaticNodes:   11,
+		ParamNodes:    3,
+		CatchAllNodes: 1,
+		Depth:         7,
+		Patterns:      7,
+		Routes:        7,
+		HostRoutes:    2,
+		TotalParams:   5,
+		MaxParams:     2,
+	}
+
+	got := router.Stats()
+	if got.Memory <= 0 {
+		t.Errorf("got Memory %d, want > 0", got.Memory)
+	}
+	got.Memory = 0
+	equals(t, 0, got, want)
+
+	router.Freeze()
+	got = router.Stats()
+	if got.Memory <= 0 {
+		t.Errorf("got Memory %d, want > 0", got.Memory)
+	}
+	got.Memory = 0
+	equals(t, 1, got, want)
+}
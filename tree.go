@@ -1,228 +1,315 @@
 package route
 
 import (
-	"context"
 	"fmt"
-	"net/http"
 	"sort"
 	"strings"
 )
 
-type paramNode struct {
-	start   byte
-	end     byte
-	name    string
-	pattern string
-	handler nodeHandler
-	child   *node
+// tree is a radix tree whose nodes, params, catch-alls and leaves are stored in
+// slices of their own and refer to each other by their indices in those slices,
+// which leaves few pointers for the garbage collector to scan and allows the
+// tree to be compacted in place, see compact. The index 0 of the params,
+// catch-alls and leaves is never used, it stands for none, the node at the
+// index 0 is the root. The zero value is an empty tree with the separator 0.
+type tree[L any] struct {
+	// The separator delimits the segments matched by the params, '/' for
+	// the trees of a Router.
+	sep byte
+	// If set, a catch-all takes the name of the last pattern registered
+	// with it rather than conflicting with the patterns that rename it,
+	// which is how the trees of a Router have always treated them.
+	renameCatchAlls bool
+
+	nodes     []node
+	params    []paramNode
+	catchalls []catchallNode
+	leaves    []leaf[L]
+	maxParams uint8
 }
 
-type catchallNode struct {
-	name    string
+// The leaf of a tree holds a pattern and its value, e.g. the nodeHandler of
+// a Router's pattern. The leaves of not-found prefixes, see node, hold
+// a value as well.
+type leaf[L any] struct {
 	pattern string
-	handler nodeHandler
+	value   L
 }
 
+// The leaf of a node is 0 unless a pattern ends at the node, which keeps the
+// nodes small in trees where most nodes are intermediate, e.g. trees with a
+// large number of static patterns.
 type node struct {
-	edge      string
-	pattern   string
-	handler   nodeHandler
-	maxParams uint8
-
-	indices string
+	edge string
+
+	// The indices hold the first byte of each child's edge, in the
+	// order of the children, which makes the search for the child
+	// cheap even for nodes with a large number of children.
+	indices  string
+	children []int32
+
+	leaf     int32
+	param    int32
+	catchall int32
+
+	// The prefix field holds the leaf of the not-found prefix that ends at
+	// the node, which is used for the requests whose path has the node's
+	// full path as its prefix but matches no pattern in the subtree.
+	prefix int32
+}
 
-	children []*node
-	param    *paramNode
-	catchall *catchallNode
+type paramNode struct {
+	start byte
+	end   byte
+	name  string
+	leaf  int32
+	child int32
+
+	// The routed field is set if a pattern, rather than only not-found
+	// prefixes, passes through the param. The lookup does not fall back
+	// to the params of the prefixes alone, which would otherwise take
+	// the requests of a catch-all at the same node.
+	routed bool
 }
 
-func (nd *node) insert(method, pattern string, h Handler) error {
-	var (
-		cn        = nd // current node
-		pat       = pattern
-		maxParams = countParams(pattern)
-	)
+type catchallNode struct {
+	name string
+	leaf int32
+}
 
-Loop:
-	for {
-		if pat == "" {
-			cn.pattern = pattern
-			return cn.handler.set(method, h)
-		}
+// init allocates the root and the unused first elements of the tree's slices.
+func (t *tree[L]) init() {
+	t.nodes = append(t.nodes[:0], node{})
+	t.params = append(t.params[:0], paramNode{})
+	t.catchalls = append(t.catchalls[:0], catchallNode{})
+	t.leaves = append(t.leaves[:0], leaf[L]{})
+}
 
-		if maxParams > cn.maxParams {
-			cn.maxParams = maxParams
-		}
+// insert inserts the pattern, split into the given parts, into the tree and
+// returns its leaf, calling set with the leaf's value. If the value is new
+// the leaf is added only if set succeeds. If prefix is true the pattern is
+// a not-found prefix whose leaf is separate from that of the same pattern.
+func (t *tree[L]) insert(pattern string, parts []Part, prefix bool, set func(v *L) error) (int32, error) {
+	if len(t.nodes) == 0 {
+		t.init()
+	}
+	if prefix && (len(parts) == 0 || parts[len(parts)-1].Kind != StaticPart) {
+		return 0, &routeError{typ: errNotFoundPrefix}
+	}
 
-		// catch-all node
-		if pat[0] == '*' {
-			if cn.catchall == nil {
-				cn.catchall = &catchallNode{}
-			}
-			if err := cn.catchall.handler.set(method, h); err != nil {
-				return err
-			}
-			cn.catchall.name = pat[1:]
-			cn.catchall.pattern = pattern
-			break
-		}
+	var (
+		ni   int32 // the current node
+		slot *int32
 
-		// parameter node
-		if pat[0] == '{' {
-			i := strings.IndexByte(pat, '}')
-			if i == -1 {
-				return &routeError{typ: errUnclosedParam}
+		ci     int32  // the catch-all node, if any
+		rename string // the name of the catch-all
+	)
+	for k, pt := range parts {
+		switch pt.Kind {
+		case StaticPart:
+			ni = t.insertStatic(ni, pt.Value)
+
+		case ParamPart:
+			if t.nodes[ni].param == 0 {
+				t.params = append(t.params, paramNode{name: pt.Value})
+				t.nodes[ni].param = int32(len(t.params) - 1)
 			}
-			name := pat[1:i]
-
-			var start, end byte
-			if len(cn.edge) > 0 {
-				start = cn.edge[len(cn.edge)-1]
+			pi := t.nodes[ni].param
+			p := &t.params[pi]
+			if p.name != "" && p.name != pt.Value {
+				return 0, &routeError{errParamConflict, pt.Value, p.name}
 			}
-			if len(pat) > (i + 1) {
-				end = pat[i+1]
+			if pt.Start != p.start && pt.Start != 0 && p.start != 0 {
+				return 0, &routeError{errSeparatorConflict, pt.Start, p.start}
 			}
-
-			if cn.param == nil {
-				cn.param = &paramNode{name: name}
+			if pt.End != p.end && pt.End != 0 && p.end != 0 {
+				return 0, &routeError{errSeparatorConflict, pt.End, p.end}
 			}
-
-			if cn.param.name != "" && cn.param.name != name {
-				return &routeError{errParamConflict, name, cn.param.name}
+			if pt.Start != 0 {
+				p.start = pt.Start
 			}
-			if start != cn.param.start {
-				if start != 0 && cn.param.start != 0 {
-					return &routeError{errSeparatorConflict, start, cn.param.start}
-				}
-				if start == 0 {
-					start = cn.param.start
-				}
+			if pt.End != 0 {
+				p.end = pt.End
 			}
-			if end != cn.param.end {
-				if end != 0 && cn.param.end != 0 {
-					return &routeError{errSeparatorConflict, end, cn.param.end}
-				}
-				if end == 0 {
-					end = cn.param.end
-				}
+			p.name = pt.Value
+			if !prefix {
+				p.routed = true
 			}
 
-			cn.param.start = start
-			cn.param.end = end
-			cn.param.name = name
-
-			pat = pat[i+1:]
-			if pat == "" {
-				cn.param.pattern = pattern
-				return cn.param.handler.set(method, h)
-			} else if cn.param.child == nil {
-				cn.param.child = &node{}
+			if k == len(parts)-1 {
+				slot = &p.leaf
+			} else if p.child == 0 {
+				t.nodes = append(t.nodes, node{})
+				t.params[pi].child = int32(len(t.nodes) - 1)
 			}
+			ni = t.params[pi].child
 
-			maxParams--
-			cn = cn.param.child
-			continue Loop
+		case CatchAllPart:
+			if t.nodes[ni].catchall == 0 {
+				t.catchalls = append(t.catchalls, catchallNode{name: pt.Value})
+				t.nodes[ni].catchall = int32(len(t.catchalls) - 1)
+			}
+			ci = t.nodes[ni].catchall
+			c := &t.catchalls[ci]
+			if c.name != pt.Value && !t.renameCatchAlls {
+				return 0, &routeError{errParamConflict, pt.Value, c.name}
+			}
+			// the catch-all is renamed once the pattern's value is set
+			rename, slot = pt.Value, &c.leaf
 		}
+	}
+	if slot == nil {
+		if slot = &t.nodes[ni].leaf; prefix {
+			slot = &t.nodes[ni].prefix
+		}
+	}
 
-		// static node
-		for i, n := range cn.children {
-			if n.edge != "" && n.edge[0] == pat[0] {
-				pl := cpl(n.edge, pat)
-				if pl < len(n.edge) {
-					// split the edge
-					prefix, suffix := n.edge[:pl], n.edge[pl:]
-					n = &node{
-						edge:    prefix,
-						indices: string([]byte{suffix[0]}),
-						children: []*node{{
-							edge:      suffix,
-							pattern:   n.pattern,
-							indices:   n.indices,
-							maxParams: n.maxParams,
-							handler:   n.handler,
-							children:  n.children,
-							param:     n.param,
-							catchall:  n.catchall,
-						}},
-					}
-
-					cn.children[i] = n
-				}
-
-				cn = n
-				pat = pat[pl:]
-
-				continue Loop
+	if *slot != 0 {
+		if set != nil {
+			if err := set(&t.leaves[*slot].value); err != nil {
+				return *slot, err
 			}
 		}
+		if ci != 0 {
+			t.catchalls[ci].name = rename
+		}
+		return *slot, nil
+	}
+	var v L
+	if set != nil {
+		if err := set(&v); err != nil {
+			return 0, err
+		}
+	}
+	t.leaves = append(t.leaves, leaf[L]{pattern: pattern, value: v})
+	*slot = int32(len(t.leaves) - 1)
+	if ci != 0 {
+		t.catchalls[ci].name = rename
+	}
+	if n := countParams(parts); n > t.maxParams {
+		t.maxParams = n
+	}
+	return *slot, nil
+}
 
-		var edge string
-		if i := strings.IndexByte(pat, '{'); i != -1 {
-			edge, pat = pat[:i], pat[i:]
-		} else if i := strings.IndexByte(pat, '*'); i != -1 {
-			edge, pat = pat[:i], pat[i:]
-		} else {
-			edge, pat = pat, ""
+// insertStatic inserts the static text s below the node ni, splitting the edges
+// that s shares only in part, and returns the node at the end of s.
+func (t *tree[L]) insertStatic(ni int32, s string) int32 {
+	for s != "" {
+		nd := &t.nodes[ni]
+		i := strings.IndexByte(nd.indices, s[0])
+		if i == -1 {
+			t.nodes = append(t.nodes, node{edge: s})
+			ci := int32(len(t.nodes) - 1)
+			nd = &t.nodes[ni]
+			nd.indices += s[:1]
+			nd.children = append(nd.children, ci)
+			return ci
 		}
 
-		n := &node{
-			edge:      edge,
-			maxParams: maxParams,
+		ci := nd.children[i]
+		c := &t.nodes[ci]
+		pl := cpl(c.edge, s)
+		if pl < len(c.edge) {
+			// split the edge, the existing node keeps its index and
+			// becomes the child of the new node with the common prefix
+			prefix, suffix := c.edge[:pl], c.edge[pl:]
+			c.edge = suffix
+			t.nodes = append(t.nodes, node{
+				edge:     prefix,
+				indices:  suffix[:1],
+				children: []int32{ci},
+			})
+			ci = int32(len(t.nodes) - 1)
+			t.nodes[ni].children[i] = ci
 		}
-		cn.indices += string([]byte{edge[0]})
-		cn.children = append(cn.children, n)
-		cn = n
+		ni, s = ci, s[pl:]
 	}
-
-	return nil
+	return ni
 }
 
-func (nd *node) lookup(path string, po Params) (h Handler, ps Params, pat string, redir tsr) {
-	ps = po[0:0]
-
-	var prev *node // track the previous node
+// lookup returns the leaf of the pattern that matches the given path, with the
+// path's params appended to po. If no pattern matches the path, lookup returns
+// the trailing slash redirect recommendation, and the leaf of the not-found
+// prefix of the deepest node that was reached during the lookup, if any. The
+// steps of the lookup are recorded in tr unless it is nil.
+func (t *tree[L]) lookup(path string, po Params, tr *trace) (lf int32, ps Params, redir tsr, prefix int32) {
+	if len(t.nodes) == 0 {
+		return 0, nil, tsrNone, 0
+	}
+	ps = po
 
-	// Track the last encountered dynamic node as well as the path at the
-	// time of the encouter, to be able to resume the lookup from that
-	// dynamic node when the static lookup is unsuccessful.
-	var dn *node
-	var dp string
+	var (
+		nd   = &t.nodes[0] // the current node
+		prev *node         // the previous node
+
+		// Track the last encountered dynamic node as well as the path at the
+		// time of the encouter, to be able to resume the lookup from that
+		// dynamic node when the static lookup is unsuccessful.
+		dn *node
+		dp string
+
+		// The full path and the length of it that was consumed when the
+		// not-found prefix was reached, to keep the deepest prefix across
+		// the fallbacks to dynamic nodes.
+		full   = path
+		pdepth int
+	)
 
 Loop:
 	for {
-		if path == "" || path == nd.edge {
-			if nd.handler.isSet {
-				pat = nd.pattern
-				h = &nd.handler
-				return
+		if nd.prefix != 0 && len(full)-len(path) >= pdepth {
+			prefix, pdepth = nd.prefix, len(full)-len(path)
+			if tr != nil {
+				tr.add("node %q has a not-found handler, it becomes the fallback", nd.edge)
+			}
+		}
+		if path == "" {
+			if nd.leaf != 0 {
+				if tr != nil {
+					tr.add("the path ends at node %q which matches the pattern %q", nd.edge, t.leaves[nd.leaf].pattern)
+				}
+				return nd.leaf, ps, tsrNone, prefix
 			}
 
-			if nd.edge == "/" && (prev != nil && prev.handler.isSet) {
-				return nil, nil, "", tsrWithoutSlash
+			if t.isSep(nd.edge) && (prev != nil && prev.leaf != 0) {
+				if tr != nil {
+					tr.add("the path ends at node %q which has no routes, but its parent node %q does:"+
+						" recommend a redirect without the trailing slash", nd.edge, prev.edge)
+				}
+				return 0, nil, tsrWithoutSlash, prefix
 			}
-			return recommend(nd, path)
+			if tr != nil {
+				tr.add("the path ends at node %q which has no routes", nd.edge)
+			}
+			return 0, nil, t.recommend(nd, path, tr), prefix
 		}
 
 		// Track the last encountered dynamic node. The param node has
 		// higher priority so if both are available make sure to handle
-		// the param node second to override the catchall node.
-		if nd.catchall != nil {
-			dn = nd
-			dp = path
-		}
-		if nd.param != nil {
-			dn = nd
-			dp = path
+		// the param node second to override the catchall node. The
+		// params of not-found prefixes alone are not tracked.
+		if nd.catchall != 0 || (nd.param != 0 && t.params[nd.param].routed) {
+			dn, dp = nd, path
+			if tr != nil {
+				tr.add("node %q has a dynamic child, remember it as the fallback for the path %q", nd.edge, path)
+			}
 		}
 
 		// static node
 		c := path[0]
 		for i := 0; i < len(nd.indices); i++ {
 			if c == nd.indices[i] {
-				n := nd.children[i]
+				n := &t.nodes[nd.children[i]]
 				if plen, elen := len(path), len(n.edge); plen >= elen && n.edge == path[:elen] {
+					if tr != nil {
+						tr.add("the edge %q of a child of node %q is a prefix of the path %q, descend", n.edge, nd.edge, path)
+					}
 					path = path[elen:]
 				} else {
+					if tr != nil {
+						tr.add("the edge %q of a child of node %q is not a prefix of the path %q", n.edge, nd.edge, path)
+					}
 					break
 				}
 
@@ -231,89 +318,188 @@ Loop:
 				continue Loop
 			}
 		}
+		if tr != nil {
+			tr.add("no static match for the path %q below node %q", path, nd.edge)
+		}
 
 		// parameter node
-		if dn != nil && dn.param != nil {
+		if dn != nil && dn.param != 0 && t.params[dn.param].routed {
+			p := &t.params[dn.param]
 			path = dp
-			elen := len(dn.edge)
-			if (elen == 0 && dn.param.start == 0) || (elen > 0 && dn.edge[elen-1] == dn.param.start) {
+			if elen := len(dn.edge); (elen == 0 && p.start == 0) || (elen > 0 && dn.edge[elen-1] == p.start) {
 				var i int
-				for plen := len(path); i < plen && (path[i] != dn.param.end && path[i] != '/'); i++ {
+				for plen := len(path); i < plen && (path[i] != p.end && path[i] != t.sep); i++ {
 				}
 
+				if ps == nil {
+					ps = make(Params, 0, t.maxParams)
+				}
 				ps = append(ps, param{
-					key: dn.param.name,
+					key: p.name,
 					val: path[:i],
 				})
+				if tr != nil {
+					tr.add("fall back to the param {%s} of node %q at the path %q, capture %s=%q",
+						p.name, dn.edge, dp, p.name, path[:i])
+				}
 
 				path = path[i:]
 				if path == "" {
-					if dn.param.handler.isSet {
-						pat = dn.param.pattern
-						h = &dn.param.handler
-						return
+					if p.leaf != 0 {
+						if tr != nil {
+							tr.add("the path ends at the param {%s} which matches the pattern %q", p.name, t.leaves[p.leaf].pattern)
+						}
+						return p.leaf, ps, tsrNone, prefix
+					}
+					if tr != nil {
+						tr.add("the path ends at the param {%s} which has no routes", p.name)
+					}
+					var child *node
+					if p.child != 0 {
+						child = &t.nodes[p.child]
+					}
+					return 0, nil, t.recommend(child, path, tr), prefix
+				} else if p.child == 0 {
+					if t.isSep(path) && p.leaf != 0 {
+						if tr != nil {
+							tr.add("only a trailing slash is left after the param {%s} which has routes:"+
+								" recommend a redirect without the trailing slash", p.name)
+						}
+						return 0, nil, tsrWithoutSlash, prefix
 					}
-					return recommend(dn.param.child, path)
-				} else if dn.param.child == nil {
-					if path == "/" && dn.param.handler.isSet {
-						return nil, nil, "", tsrWithoutSlash
+					if tr != nil {
+						tr.add("the path %q is left after the param {%s} which has no children", path, p.name)
 					}
-					return nil, nil, "", tsrNone
+					return 0, nil, tsrNone, prefix
 				}
 
 				prev = dn
-				nd = dn.param.child
+				nd = &t.nodes[p.child]
 				dn = nil
 				continue
 			}
+			if tr != nil {
+				tr.add("the param {%s} of node %q does not start after the separator %q",
+					p.name, dn.edge, separator(p.start))
+			}
 		}
 
 		// catch-all node
-		if dn != nil && dn.catchall != nil {
+		if dn != nil && dn.catchall != 0 {
+			ca := &t.catchalls[dn.catchall]
 			path = dp
+			if ps == nil {
+				ps = make(Params, 0, t.maxParams)
+			}
 			ps = append(ps, param{
-				key: dn.catchall.name,
+				key: ca.name,
 				val: path,
 			})
+			if tr != nil {
+				tr.add("fall back to the catch-all *%s of node %q, capture %s=%q", ca.name, dn.edge, ca.name, path)
+			}
 
-			if !dn.catchall.handler.isSet {
-				return nil, nil, "", tsrNone
+			if ca.leaf != 0 {
+				if tr != nil {
+					tr.add("the catch-all *%s matches the pattern %q", ca.name, t.leaves[ca.leaf].pattern)
+				}
+				return ca.leaf, ps, tsrNone, prefix
+			}
+			if tr != nil {
+				tr.add("the catch-all *%s has no routes", ca.name)
 			}
-			pat = dn.catchall.pattern
-			h = &dn.catchall.handler
+			return 0, nil, tsrNone, prefix
 		}
 
+		if dn == nil && tr != nil {
+			tr.add("there is no dynamic node to fall back to")
+		}
 		break Loop
 	}
 
-	if h == nil {
-		if path == "/" && nd.handler.isSet {
-			return nil, nil, "", tsrWithoutSlash
+	if t.isSep(path) && nd.leaf != 0 {
+		if tr != nil {
+			tr.add("only a trailing slash is left after node %q which has routes:"+
+				" recommend a redirect without the trailing slash", nd.edge)
 		}
-		return recommend(nd, path)
+		return 0, nil, tsrWithoutSlash, prefix
 	}
-	return
+	return 0, nil, t.recommend(nd, path, tr), prefix
 }
 
-func recommend(nd *node, path string) (h Handler, ps Params, pat string, redir tsr) {
-	if plen := len(path); plen == 0 || path[plen-1] != '/' {
-		path += "/"
-		if nd != nil {
-			for _, n := range nd.children {
-				if n.edge == path && n.handler.isSet {
-					return nil, nil, "", tsrWithSlash
-				}
+// isSep reports whether s is the tree's separator.
+func (t *tree[L]) isSep(s string) bool {
+	return len(s) == 1 && s[0] == t.sep
+}
+
+// recommend returns the trailing slash redirect recommendation for the path
+// at the node nd, i.e. whether a child of nd with a pattern has the path with
+// a trailing separator as its edge, and records the reason in tr.
+func (t *tree[L]) recommend(nd *node, path string, tr *trace) tsr {
+	if plen := len(path); nd == nil || (plen > 0 && path[plen-1] == t.sep) {
+		return tsrNone
+	}
+	for _, ci := range nd.children {
+		if c := &t.nodes[ci]; c.leaf != 0 && len(c.edge) == len(path)+1 &&
+			c.edge[len(path)] == t.sep && c.edge[:len(path)] == path {
+			if tr != nil {
+				tr.add("node %q has the child %q which has routes: recommend a redirect"+
+					" with a trailing slash", nd.edge, c.edge)
 			}
+			return tsrWithSlash
 		}
 	}
-	return nil, nil, "", tsrNone
+	return tsrNone
 }
 
-func countParams(pattern string) (n uint8) {
-	for i := 0; i < len(pattern); i++ {
-		if pattern[i] == '*' {
-			return n + 1
-		} else if pattern[i] == '{' {
+// walk calls fn for the leaf of every pattern in the tree. The tree is walked
+// depth-first, for each node the static children are visited first, then the
+// param node and then the catch-all node. The leaves of the not-found prefixes
+// are not visited.
+func (t *tree[L]) walk(fn func(l *leaf[L]) error) error {
+	if len(t.nodes) == 0 {
+		return nil
+	}
+	return t.walkNode(0, fn)
+}
+
+func (t *tree[L]) walkNode(ni int32, fn func(l *leaf[L]) error) error {
+	nd := &t.nodes[ni]
+	if nd.leaf != 0 {
+		if err := fn(&t.leaves[nd.leaf]); err != nil {
+			return err
+		}
+	}
+	for _, ci := range nd.children {
+		if err := t.walkNode(ci, fn); err != nil {
+			return err
+		}
+	}
+	if nd.param != 0 {
+		p := &t.params[nd.param]
+		if p.leaf != 0 {
+			if err := fn(&t.leaves[p.leaf]); err != nil {
+				return err
+			}
+		}
+		if p.child != 0 {
+			if err := t.walkNode(p.child, fn); err != nil {
+				return err
+			}
+		}
+	}
+	if nd.catchall != 0 {
+		if c := &t.catchalls[nd.catchall]; c.leaf != 0 {
+			return fn(&t.leaves[c.leaf])
+		}
+	}
+	return nil
+}
+
+// countParams returns the number of params and catch-alls in the parts of a pattern.
+func countParams(parts []Part) (n uint8) {
+	for _, pt := range parts {
+		if pt.Kind != StaticPart {
 			n++
 		}
 	}
@@ -337,64 +523,180 @@ func min(x, y int) int {
 	return y
 }
 
-type nodeHandler struct {
-	isSet bool // isSet reports whether at least one Handler is set in the hm field.
+// The standard http methods, in lexicographical order, each at the index
+// of the nodeHandler's table slot that holds the method's Route.
+var stdMethods = [numStdMethods]string{
+	"CONNECT",
+	"DELETE",
+	"GET",
+	"HEAD",
+	"OPTIONS",
+	"PATCH",
+	"POST",
+	"PUT",
+	"TRACE",
+}
 
-	// The hm field is a map that associates Handlers with http methods.
-	hm map[string]Handler
+const numStdMethods = 9
+
+// methodIndex returns the index of the given standard http method
+// in the stdMethods array, or -1 if the method is not standard.
+func methodIndex(m string) int {
+	switch m {
+	case "CONNECT":
+		return 0
+	case "DELETE":
+		return 1
+	case "GET":
+		return 2
+	case "HEAD":
+		return 3
+	case "OPTIONS":
+		return 4
+	case "PATCH":
+		return 5
+	case "POST":
+		return 6
+	case "PUT":
+		return 7
+	case "TRACE":
+		return 8
+	}
+	return -1
+}
+
+type nodeHandler struct {
+	// The table field holds the Routes of the standard http methods,
+	// each at the index returned by methodIndex for that method.
+	table [numStdMethods]*Route
+	// The any field holds the Route registered for the "*" method.
+	any *Route
+	// The ext field associates Routes with non-standard http methods.
+	ext map[string]*Route
 
 	// The methods field contains a string of lexicographically sorted comma
 	// separated http methods that can be handled by the node.
 	methods string
+
+	// The notFound field holds the Handler of a not-found prefix, which
+	// has no Routes, see Router.SetNotFoundFor.
+	notFound Handler
 }
 
-// ServeHTTP implements the route.Handler interface.
-func (nh *nodeHandler) ServeHTTP(c context.Context, w http.ResponseWriter, r *http.Request) {
-	h := nh.hm[r.Method]
-	if h == nil {
-		h = nh.hm["*"]
+// get returns the Route registered for the given method, falling back
+// to the "*" Route. If neither is registered get returns nil.
+func (nh *nodeHandler) get(method string) *Route {
+	if i := methodIndex(method); i >= 0 {
+		if rt := nh.table[i]; rt != nil {
+			return rt
+		}
+	} else if rt := nh.ext[method]; rt != nil {
+		return rt
+	}
+	return nh.any
+}
+
+// list returns the distinct Routes of the node handler ordered by the
+// first of their methods, with the "*" Route, if any, being first.
+func (nh *nodeHandler) list() []*Route {
+	var rts []*Route
+	add := func(rt *Route) {
+		if rt != nil && !containsRoute(rts, rt) {
+			rts = append(rts, rt)
+		}
+	}
+
+	add(nh.any)
+	for _, m := range nh.sortedMethods() {
+		add(nh.lookupMethod(m))
 	}
-	if h == nil {
-		w.Header().Set("Allow", nh.methods)
-		if r.Method != "OPTIONS" {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	return rts
+}
+
+func containsRoute(rts []*Route, rt *Route) bool {
+	for _, r := range rts {
+		if r == rt {
+			return true
 		}
-	} else {
-		h.ServeHTTP(c, w, r)
 	}
+	return false
 }
 
-func (nh *nodeHandler) set(method string, h Handler) error {
-	if nh.hm == nil {
-		nh.hm = map[string]Handler{}
+// lookupMethod returns the Route registered for the given method
+// without falling back to the "*" Route.
+func (nh *nodeHandler) lookupMethod(m string) *Route {
+	if m == "*" {
+		return nh.any
+	}
+	if i := methodIndex(m); i >= 0 {
+		return nh.table[i]
+	}
+	return nh.ext[m]
+}
+
+// sortedMethods returns the lexicographically sorted methods, other
+// than "*", for which the node handler has a Route registered.
+func (nh *nodeHandler) sortedMethods() []string {
+	var methods []string
+	for i, rt := range nh.table {
+		if rt != nil {
+			methods = append(methods, stdMethods[i])
+		}
 	}
+	if len(nh.ext) > 0 {
+		for m := range nh.ext {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+	}
+	return methods
+}
 
-	ms := strings.Split(method, ",")
-	for _, m := range ms {
+func (nh *nodeHandler) set(rt *Route) error {
+	for _, m := range rt.Methods {
 		if m == "" {
 			return fmt.Errorf("Missing method")
 		}
-		if _, ok := nh.hm[m]; ok {
+		if nh.lookupMethod(m) != nil {
 			return &routeError{typ: errMethodConflict, a: m}
 		}
-		nh.hm[m] = h
-	}
-	nh.isSet = true
 
-	// On each call to "set" re-iterate over all methods, sort them and
-	// set the resulting value to the methods field.
-	var methods []string
-	for m, _ := range nh.hm {
+		if m == "*" {
+			nh.any = rt
+		} else if i := methodIndex(m); i >= 0 {
+			nh.table[i] = rt
+		} else {
+			if nh.ext == nil {
+				nh.ext = make(map[string]*Route)
+			}
+			nh.ext[m] = rt
+		}
 		if m != "*" {
-			methods = append(methods, m)
+			nh.methods = insertMethod(nh.methods, m)
 		}
 	}
-	sort.Strings(methods)
-	nh.methods = strings.Join(methods, ",")
-
 	return nil
 }
 
+// insertMethod inserts the method m into the lexicographically sorted comma
+// separated methods and returns the result.
+func insertMethod(methods, m string) string {
+	for i := 0; i < len(methods); {
+		j := strings.IndexByte(methods[i:], ',')
+		if j == -1 {
+			j = len(methods) - i
+		}
+		if m < methods[i:i+j] {
+			return methods[:i] + m + "," + methods[i:]
+		}
+		i += j + 1
+	}
+	if methods == "" {
+		return m
+	}
+	return methods + "," + m
+}
+
 type errorType int
 
 const (
@@ -402,6 +704,8 @@ const (
 	errParamConflict
 	errSeparatorConflict
 	errMethodConflict
+	errNotFoundPrefix
+	errRepeatedParam
 )
 
 type routeError struct {
@@ -421,6 +725,10 @@ func (e *routeError) Error() string {
 			"separator '%c' in the same location of a previously registered pattern.", e.a, e.b)
 	case errMethodConflict:
 		return fmt.Sprintf("A handler for the %q method is already registered.", e.a)
+	case errRepeatedParam:
+		return fmt.Sprintf("The repeated param %q must be the last segment of the pattern.", e.a)
+	case errNotFoundPrefix:
+		return "A not-found prefix must end with a slash '/'."
 	default:
 		return "unknown error"
 	}
@@ -0,0 +1,188 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tree is a radix tree that maps patterns to values of type V. The patterns have
+// the same params and catch-alls as the patterns of a Router, and are matched the
+// same way, except that the segments are delimited by the Tree's separator rather
+// than by '/'. A Tree can therefore be used to route keys other than URL paths,
+// e.g. message topics such as "orders.{id}.created" with the separator '.'.
+//
+// Like the Router, a Tree prefers static matches to params, and params to
+// catch-alls, and the lookup falls back to the last param or catch-all that
+// it passed, without backtracking further. Unlike the Router, a Tree knows
+// nothing of methods, hosts or trailing slash redirects.
+//
+// A Tree is not safe for concurrent use if any of the goroutines modifies it.
+type Tree[V any] struct {
+	tree tree[V]
+	size int
+}
+
+// NewTree returns a new Tree whose params match the segments of
+// the keys delimited by sep, or by their own separators.
+func NewTree[V any](sep byte) *Tree[V] {
+	return &Tree[V]{tree: tree[V]{sep: sep}}
+}
+
+// Len returns the number of patterns in the Tree.
+func (t *Tree[V]) Len() int {
+	return t.size
+}
+
+// Insert sets v as the value of the pattern, replacing its previous value if any.
+// Insert returns an error if the pattern is malformed or if it conflicts with a
+// pattern in the Tree, e.g. if it has a param with a different name in the same
+// place, the same way the Router's Handle method would panic.
+func (t *Tree[V]) Insert(pattern string, v V) error {
+	parts, err := parsePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("route: %s: %w", pattern, err)
+	}
+	n := len(t.tree.leaves)
+	if _, err := t.tree.insert(pattern, parts, false, func(l *V) error {
+		*l = v
+		return nil
+	}); err != nil {
+		return fmt.Errorf("route: %s: %w", pattern, err)
+	}
+	if len(t.tree.leaves) > n {
+		t.size++
+	}
+	return nil
+}
+
+// Lookup returns the value of the pattern that matches the key, together
+// with the key's params, and reports whether a pattern matched the key.
+func (t *Tree[V]) Lookup(key string) (v V, ps Params, ok bool) {
+	lf, ps, _, _ := t.tree.lookup(key, nil, nil)
+	if lf == 0 {
+		return v, nil, false
+	}
+	return t.tree.leaves[lf].value, ps, true
+}
+
+// LookupPattern is like Lookup but it returns the pattern that matched the key
+// instead of its value, e.g. "orders.{id}.created" for "orders.42.created".
+func (t *Tree[V]) LookupPattern(key string) (pattern string, ps Params, ok bool) {
+	lf, ps, _, _ := t.tree.lookup(key, nil, nil)
+	if lf == 0 {
+		return "", nil, false
+	}
+	return t.tree.leaves[lf].pattern, ps, true
+}
+
+// Delete removes the pattern from the Tree and reports whether the Tree held
+// the pattern. The pattern must be written exactly as it was inserted, with
+// the same param names. The nodes left without patterns are pruned.
+func (t *Tree[V]) Delete(pattern string) bool {
+	parts, err := parsePattern(pattern)
+	if err != nil || len(t.tree.nodes) == 0 || !t.tree.remove(0, "", parts) {
+		return false
+	}
+	t.size--
+
+	// the pruned nodes and the deleted leaves are only unlinked from
+	// the tree, it is compacted once they outnumber the patterns
+	if t.size < len(t.tree.leaves)/2 {
+		t.tree.compact()
+	}
+	return true
+}
+
+// remove removes the leaf of the pattern from the subtree rooted at the node ni
+// and prunes the nodes left without patterns. The pattern's parts are followed
+// the same way insert follows them, s being the rest of the static text that
+// precedes them, except that remove never splits an edge.
+func (t *tree[L]) remove(ni int32, s string, parts []Part) bool {
+	if s == "" && len(parts) > 0 && parts[0].Kind == StaticPart {
+		s, parts = parts[0].Value, parts[1:]
+	}
+	nd := &t.nodes[ni]
+
+	switch {
+	case s == "" && len(parts) == 0:
+		if nd.leaf == 0 {
+			return false
+		}
+		t.leaves[nd.leaf] = leaf[L]{}
+		nd.leaf = 0
+		return true
+
+	case s == "" && parts[0].Kind == CatchAllPart:
+		if nd.catchall == 0 {
+			return false
+		}
+		c := &t.catchalls[nd.catchall]
+		if c.name != parts[0].Value || c.leaf == 0 {
+			return false
+		}
+		t.leaves[c.leaf] = leaf[L]{}
+		nd.catchall = 0
+		return true
+
+	case s == "":
+		if nd.param == 0 || t.params[nd.param].name != parts[0].Value {
+			return false
+		}
+		p := &t.params[nd.param]
+		if len(parts) == 1 {
+			if p.leaf == 0 {
+				return false
+			}
+			t.leaves[p.leaf] = leaf[L]{}
+			p.leaf = 0
+		} else {
+			if p.child == 0 || !t.remove(p.child, "", parts[1:]) {
+				return false
+			}
+			if t.empty(p.child) {
+				p.child = 0
+			}
+		}
+		if p.leaf == 0 && p.child == 0 {
+			nd.param = 0
+		}
+		return true
+	}
+
+	i := strings.IndexByte(nd.indices, s[0])
+	if i == -1 {
+		return false
+	}
+	ci := nd.children[i]
+	c := &t.nodes[ci]
+	if !strings.HasPrefix(s, c.edge) || !t.remove(ci, s[len(c.edge):], parts) {
+		return false
+	}
+
+	switch {
+	case t.empty(ci):
+		nd.indices = nd.indices[:i] + nd.indices[i+1:]
+		nd.children = append(nd.children[:i], nd.children[i+1:]...)
+	case c.leaf == 0 && c.prefix == 0 && c.param == 0 && c.catchall == 0 && len(c.children) == 1:
+		// merge the node with its only child, the reverse of an edge split
+		gc := &t.nodes[c.children[0]]
+		gc.edge = c.edge + gc.edge
+		nd.children[i] = c.children[0]
+	}
+	return true
+}
+
+// empty reports whether the node ni has neither a pattern nor children.
+func (t *tree[L]) empty(ni int32) bool {
+	nd := &t.nodes[ni]
+	return nd.leaf == 0 && nd.prefix == 0 && nd.param == 0 && nd.catchall == 0 && len(nd.children) == 0
+}
+
+// Walk calls fn for each pattern in the Tree, and its value, in the order of
+// the tree: the static patterns before the params, and the params before the
+// catch-alls. If fn returns an error, Walk stops and returns that error.
+func (t *Tree[V]) Walk(fn func(pattern string, v V) error) error {
+	return t.tree.walk(func(l *leaf[V]) error {
+		return fn(l.pattern, l.value)
+	})
+}
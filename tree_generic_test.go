@@ -0,0 +1,238 @@
+package route
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The Tree with the separator '/' must match paths the same way the Router does.
+func TestTree_Router(t *testing.T) {
+	//t.Skip()
+	for _, routes := range [][]benchRoute{staticRoutes, githubRoutes, parseRoutes} {
+		router := benchRouter(routes)
+		tree := NewTree[string]('/')
+		for _, rt := range routes {
+			if err := tree.Insert(rt.path, rt.path); err != nil {
+				t.Fatalf("%s: unexpected error %v", rt.path, err)
+			}
+		}
+
+		for _, rt := range routes {
+			p := benchPath(rt.path)
+			for _, path := range []string{p, p + "/", strings.TrimSuffix(p, "/"), p + "x", p + "/x", "/x" + p} {
+				_, ps, pat := router.Handler(mustNewRequest(rt.method, path, nil))
+				v, tps, ok := tree.Lookup(path)
+				tpat, _, _ := tree.LookupPattern(path)
+				if len(ps) == 0 || pat == "" {
+					ps = nil // the Router returns empty params for static patterns
+				}
+				if v != pat || tpat != pat || ok != (pat != "") || !reflect.DeepEqual(tps, ps) {
+					t.Errorf("%s: got (%q, %v, %t), want (%q, %v)", path, v, tps, ok, pat, ps)
+				}
+			}
+		}
+	}
+}
+
+func TestTree_Separator(t *testing.T) {
+	//t.Skip()
+	tree := NewTree[int]('.')
+	for i, pattern := range []string{
+		"orders.created",
+		"orders.{id}.created",
+		"orders.{id}.items.{item}",
+		"orders.{id}.{event}",
+		"users.{name}/{tag}",
+		"logs.*rest",
+		"metrics.{names...}",
+	} {
+		if err := tree.Insert(pattern, i); err != nil {
+			t.Fatalf("%s: unexpected error %v", pattern, err)
+		}
+	}
+
+	tests := []struct {
+		key    string
+		value  int
+		params Params
+		ok     bool
+	}{
+		{"orders.created", 0, nil, true},
+		{"orders.42.created", 1, NewParams("id", "42"), true},
+		{"orders.42.items.7", 2, NewParams("id", "42", "item", "7"), true},
+		{"orders.42.paid", 3, NewParams("id", "42", "event", "paid"), true},
+		{"orders.4/2.paid", 3, NewParams("id", "4/2", "event", "paid"), true},
+		{"users.jane/admin", 4, NewParams("name", "jane", "tag", "admin"), true},
+		{"logs.app.error", 5, NewParams("rest", "app.error"), true},
+		{"metrics.cpu.mem", 6, NewParams("names", "cpu.mem"), true},
+		{"orders.42", 0, nil, false},
+		{"orders.42.paid.x", 0, nil, false},
+		{"payments.1", 0, nil, false},
+	}
+	for i, tt := range tests {
+		v, ps, ok := tree.Lookup(tt.key)
+		equals(t, i, v, tt.value)
+		equals(t, i, ps, tt.params)
+		equals(t, i, ok, tt.ok)
+	}
+}
+
+func TestTree_EdgeRepeatedInKey(t *testing.T) {
+	//t.Skip()
+	// the rest of the key equals the edge of the node that consumed its first
+	// part, the lookup must descend into the node's children instead of
+	// stopping at the node
+	tree := NewTree[string]('/')
+	for _, pattern := range []string{"/x/{id}/a-1", "/x/{id}/a-11", "/x/{id}/a-12", "/x/a-1", "/x/a-11"} {
+		if err := tree.Insert(pattern, pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, tt := range []struct {
+		key  string
+		want string
+	}{
+		{"/x/5/a-1", "/x/{id}/a-1"},
+		{"/x/5/a-11", "/x/{id}/a-11"},
+		{"/x/5/a-12", "/x/{id}/a-12"},
+		{"/x/a-1", "/x/a-1"},
+		{"/x/a-11", "/x/a-11"},
+		{"/x/5/a-111", ""},
+	} {
+		got, _, _ := tree.LookupPattern(tt.key)
+		equals(t, i, got, tt.want)
+	}
+}
+
+func TestTree_Insert(t *testing.T) {
+	//t.Skip()
+	tree := NewTree[string]('/')
+	if err := tree.Insert("/users/{id}", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Insert("/users/{id}", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, _ := tree.Lookup("/users/1"); v != "b" || tree.Len() != 1 {
+		t.Errorf("got %q and %d patterns, want the replaced value and 1 pattern", v, tree.Len())
+	}
+
+	for i, tt := range []struct {
+		pattern string
+		err     string
+	}{
+		{"/users/{name}", `route: /users/{name}: The param name "name" conflicts with the param name "id" in the same segment of a previously registered pattern.`},
+		{"/users/{id", "route: /users/{id: missing closing curly brace '}'"},
+		{"/tags/{tags...}/x", `route: /tags/{tags...}/x: The repeated param "tags..." must be the last segment of the pattern.`},
+		{"/files/*a", ""},
+		{"/files/*b", `route: /files/*b: The param name "b" conflicts with the param name "a" in the same segment of a previously registered pattern.`},
+	} {
+		err := tree.Insert(tt.pattern, "x")
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("#%d: got error %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+func TestTree_Delete(t *testing.T) {
+	//t.Skip()
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, rt := range githubRoutes {
+		if !seen[rt.path] {
+			seen[rt.path] = true
+			patterns = append(patterns, rt.path)
+		}
+	}
+
+	tree := NewTree[string]('/')
+	for _, p := range patterns {
+		tree.Insert(p, p)
+	}
+
+	// delete every other pattern, the tree must then match
+	// the paths the same way as a tree of the remaining ones
+	var kept []string
+	for i, p := range patterns {
+		if i%2 == 0 {
+			kept = append(kept, p)
+			continue
+		}
+		if !tree.Delete(p) {
+			t.Errorf("%s: not deleted", p)
+		}
+		if tree.Delete(p) {
+			t.Errorf("%s: deleted twice", p)
+		}
+	}
+	want := NewTree[string]('/')
+	for _, p := range kept {
+		want.Insert(p, p)
+	}
+	equals(t, 0, tree.Len(), len(kept))
+	equals(t, 0, walkPatterns(tree), walkPatterns(want))
+	for _, p := range patterns {
+		path := benchPath(p)
+		v1, ps1, ok1 := tree.Lookup(path)
+		v2, ps2, ok2 := want.Lookup(path)
+		if v1 != v2 || !reflect.DeepEqual(ps1, ps2) || ok1 != ok2 {
+			t.Errorf("%s: got (%q, %v, %t), want (%q, %v, %t)", path, v1, ps1, ok1, v2, ps2, ok2)
+		}
+	}
+
+	// a pattern is deleted only if written the same way it was inserted
+	for _, p := range []string{"/users/{name}", "/user", "/authorizations/{id}/x", ""} {
+		if tree.Delete(p) {
+			t.Errorf("%s: deleted a pattern that is not in the tree", p)
+		}
+	}
+
+	for _, p := range kept {
+		if !tree.Delete(p) {
+			t.Errorf("%s: not deleted", p)
+		}
+	}
+	equals(t, 0, tree.Len(), 0)
+	if !tree.tree.empty(0) {
+		t.Errorf("the tree has nodes left after deleting every pattern")
+	}
+}
+
+func TestTree_Walk(t *testing.T) {
+	//t.Skip()
+	tree := NewTree[int]('/')
+	for i, p := range []string{"/a", "/a/{b}", "/a/{b}/c", "/a/*d", "/e"} {
+		tree.Insert(p, i)
+	}
+	var got []string
+	tree.Walk(func(pattern string, v int) error {
+		got = append(got, pattern)
+		return nil
+	})
+	equals(t, 0, got, []string{"/a", "/a/{b}", "/a/{b}/c", "/a/*d", "/e"})
+
+	stop := errors.New("stop")
+	n := 0
+	err := tree.Walk(func(string, int) error {
+		if n++; n == 2 {
+			return stop
+		}
+		return nil
+	})
+	equals(t, 0, err, stop)
+	equals(t, 0, n, 2)
+}
+
+func walkPatterns(t *Tree[string]) []string {
+	var patterns []string
+	t.Walk(func(pattern string, _ string) error {
+		patterns = append(patterns, pattern)
+		return nil
+	})
+	sort.Strings(patterns)
+	return patterns
+}
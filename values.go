@@ -0,0 +1,202 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// RequestValues provides a unified view of a request's path parameters and query
+// string values through the same typed methods that Params provides. A key that
+// is present in both the path and the query resolves to the path parameter, the
+// query values of a key are only consulted when no path parameter has that key.
+type RequestValues struct {
+	Path  Params
+	Query url.Values
+}
+
+// Values returns the RequestValues of the request r, whose path parameters are
+// those stored in the Context c.
+func Values(c context.Context, r *http.Request) RequestValues {
+	return RequestValues{Path: GetParams(c), Query: r.URL.Query()}
+}
+
+// The get method returns the value associated with the given key as a single
+// element Params, or an empty Params if there is no value for the key, which
+// allows the typed methods to reuse the parsing and errors of those of Params.
+func (v RequestValues) get(key string) Params {
+	if val, ok := v.Path.get(key); ok {
+		return Params{{key: key, val: val}}
+	}
+	if vals := v.Query[key]; len(vals) > 0 {
+		return Params{{key: key, val: vals[0]}}
+	}
+	return nil
+}
+
+// lookup implements the valueSource interface.
+func (v RequestValues) lookup(tag, key string) ([]string, bool) {
+	switch tag {
+	case "path", "route":
+		return v.Path.lookup(tag, key)
+	case "query":
+		vals, ok := v.Query[key]
+		return vals, ok
+	}
+	return nil, false
+}
+
+// Has reports whether there is a value associated with the given key.
+func (v RequestValues) Has(key string) bool {
+	return v.get(key) != nil
+}
+
+// All returns all the values associated with the given key. If there is a
+// path parameter with the key, its value is the only one returned, otherwise
+// all the query values of the key are returned.
+func (v RequestValues) All(key string) []string {
+	if val, ok := v.Path.get(key); ok {
+		return []string{val}
+	}
+	return v.Query[key]
+}
+
+// Bool returns the value associated with the given key parsed into a bool. If there is no value
+// associated with the key, or it cannot be parsed into a bool an error will be returned.
+func (v RequestValues) Bool(key string) (bool, error) {
+	return v.get(key).Bool(key)
+}
+
+// GetBool is a "convenience" wrapper around Bool that ignores errors.
+func (v RequestValues) GetBool(key string) bool {
+	return v.get(key).GetBool(key)
+}
+
+// String returns the value associated with the given key. If there is no value
+// associated with the key an error will be returned.
+func (v RequestValues) String(key string) (string, error) {
+	return v.get(key).String(key)
+}
+
+// GetString is a "convenience" wrapper around String that ignores errors.
+func (v RequestValues) GetString(key string) string {
+	return v.get(key).GetString(key)
+}
+
+// Int returns the value associated with the given key parsed into an int. If there is no value
+// associated with the key, or it cannot be parsed into an int an error will be returned.
+func (v RequestValues) Int(key string) (int, error) {
+	return v.get(key).Int(key)
+}
+
+// GetInt is a "convenience" wrapper around Int that ignores errors.
+func (v RequestValues) GetInt(key string) int {
+	return v.get(key).GetInt(key)
+}
+
+// Int64 returns the value associated with the given key parsed into an int64. If there is no value
+// associated with the key, or it cannot be parsed into an int64 an error will be returned.
+func (v RequestValues) Int64(key string) (int64, error) {
+	return v.get(key).Int64(key)
+}
+
+// GetInt64 is a "convenience" wrapper around Int64 that ignores errors.
+func (v RequestValues) GetInt64(key string) int64 {
+	return v.get(key).GetInt64(key)
+}
+
+// Uint returns the value associated with the given key parsed into a uint. If there is no value
+// associated with the key, or it cannot be parsed into a uint an error will be returned.
+func (v RequestValues) Uint(key string) (uint, error) {
+	return v.get(key).Uint(key)
+}
+
+// GetUint is a "convenience" wrapper around Uint that ignores errors.
+func (v RequestValues) GetUint(key string) uint {
+	return v.get(key).GetUint(key)
+}
+
+// Uint64 returns the value associated with the given key parsed into a uint64. If there is no value
+// associated with the key, or it cannot be parsed into a uint64 an error will be returned.
+func (v RequestValues) Uint64(key string) (uint64, error) {
+	return v.get(key).Uint64(key)
+}
+
+// GetUint64 is a "convenience" wrapper around Uint64 that ignores errors.
+func (v RequestValues) GetUint64(key string) uint64 {
+	return v.get(key).GetUint64(key)
+}
+
+// Float returns the value associated with the given key parsed into a float64. If there is no value
+// associated with the key, or it cannot be parsed into a float64 an error will be returned.
+func (v RequestValues) Float(key string) (float64, error) {
+	return v.get(key).Float(key)
+}
+
+// GetFloat is a "convenience" wrapper around Float that ignores errors.
+func (v RequestValues) GetFloat(key string) float64 {
+	return v.get(key).GetFloat(key)
+}
+
+// Time returns the value associated with the given key parsed into a time.Time. The value
+// is parsed using the time.Parse function and the specified layout. If there is no value
+// associated with the key, or it cannot be parsed into a time.Time an error will be returned.
+func (v RequestValues) Time(key, layout string) (time.Time, error) {
+	return v.get(key).Time(key, layout)
+}
+
+// GetTime is a "convenience" wrapper around Time that ignores errors.
+func (v RequestValues) GetTime(key, layout string) time.Time {
+	return v.get(key).GetTime(key, layout)
+}
+
+// Decode stores the path parameters and query values in the fields of the struct
+// pointed to by dst. Fields tagged with "path", or "route", are decoded from the
+// path parameters and fields tagged with "query" are decoded from the query values,
+// e.g.
+//
+//	type ListComments struct {
+//		PostID int      `path:"post_id"`
+//		Page   int      `query:"page"`
+//		Tags   []string `query:"tag"`
+//	}
+//
+// Slice fields tagged with "query" are decoded from all the values of the key,
+// one value per element. Otherwise Decode follows the rules of Params.Decode.
+func (v RequestValues) Decode(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("route: Decode requires a non-nil pointer to a struct, got %T", dst)
+	}
+
+	var de DecodeError
+	decodeStruct(rv.Elem(), &de, v, "path", "query", "route")
+	if len(de.Errors) > 0 {
+		return &de
+	}
+	return nil
+}
+
+// GetAll returns all the values associated with the given key in v, see the All
+// method, parsed into a []T. If there are no values associated with the key, or
+// any of them cannot be parsed into a T an error of type *ParamError will be returned.
+func GetAll[T any](v RequestValues, key string) ([]T, error) {
+	vals := v.All(key)
+	if len(vals) == 0 {
+		var zero T
+		return nil, noParam(key, "[]"+reflect.TypeOf(&zero).Elem().String())
+	}
+
+	out := make([]T, len(vals))
+	for i, val := range vals {
+		x, err := Get[T](Params{{key: key, val: val}}, key)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = x
+	}
+	return out, nil
+}
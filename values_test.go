@@ -0,0 +1,55 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	var v RequestValues
+	router := NewRouter()
+	router.HandleFunc("GET", "/posts/{post_id}/comments", func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		v = Values(c, r)
+	})
+	router.ServeHTTP(newRecorder(), mustNewRequest("GET", "/posts/12/comments?post_id=99&page=3&tag=go&tag=http&draft=yes", nil))
+
+	equals(t, 0, v.GetInt("post_id"), 12)
+	equals(t, 1, v.GetInt("page"), 3)
+	equals(t, 2, v.All("tag"), []string{"go", "http"})
+	equals(t, 3, v.All("post_id"), []string{"12"})
+	equals(t, 4, v.Has("tag"), true)
+	equals(t, 5, v.Has("foo"), false)
+
+	_, err := v.Bool("draft")
+	equals(t, 6, errors.Is(err, ErrParamMalformed), true)
+	_, err = v.Int("foo")
+	equals(t, 7, errors.Is(err, ErrParamMissing), true)
+
+	tags, err := GetAll[string](v, "tag")
+	equals(t, 8, tags, []string{"go", "http"})
+	equals(t, 9, err, nil)
+
+	var dst struct {
+		PostID int      `path:"post_id"`
+		Page   *int     `query:"page"`
+		Tags   []string `query:"tag"`
+		Limit  int      `query:"limit"`
+	}
+	if err := v.Decode(&dst); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 10, dst.PostID, 12)
+	equals(t, 11, *dst.Page, 3)
+	equals(t, 12, dst.Tags, []string{"go", "http"})
+	equals(t, 13, dst.Limit, 0)
+
+	var bad struct {
+		Draft bool `query:"draft"`
+	}
+	var de *DecodeError
+	if err := v.Decode(&bad); !errors.As(err, &de) || de.Errors[0].Key != "draft" {
+		t.Errorf("got err %v, want *DecodeError for draft", err)
+	}
+}